@@ -0,0 +1,74 @@
+﻿package main
+
+import (
+	"context"
+	"eclipse/internal/token"
+	"eclipse/pkg/services/rpcpool"
+	"eclipse/storage"
+	"eclipse/utils/balance"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func balancesCommand(ctx context.Context, p paths, args []string) error {
+	fs := flag.NewFlagSet("balances", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := p.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	wallets, err := storage.LoadWallets(p.evmKeys(), p.eclipseKeys())
+	if err != nil {
+		return err
+	}
+
+	pool := rpcpool.New(*cfg.RPC)
+	pool.Start(ctx)
+	client := pool.Client()
+
+	var symbols []string
+	for _, t := range cfg.Orca.Tokens {
+		symbols = append(symbols, t.Symbol)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "#\tECLIPSE\t%s\n", strings.Join(symbols, "\t"))
+
+	for i, acc := range wallets.Eclipse {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		row := []string{fmt.Sprint(i + 1), acc.PublicKey.String()}
+		for _, t := range cfg.Orca.Tokens {
+			params := token.SwapInstructions{
+				Payer:         acc.PrivateKey,
+				FirstToken:    t.Address,
+				IsETH:         t.Address.Equals(solana.SolMint),
+				TokenSymbol:   t.Symbol,
+				TokenDecimals: t.Decimals,
+			}
+
+			amount, err := balance.GetTokenBalance(ctx, client, params)
+			if err != nil {
+				row = append(row, "—")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.6f", float64(amount)/math.Pow(10, float64(t.Decimals))))
+		}
+
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
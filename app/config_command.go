@@ -0,0 +1,22 @@
+﻿package main
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"flag"
+)
+
+func validateConfigCommand(ctx context.Context, p paths, args []string) error {
+	fs := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := p.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	logger.Success("Конфиг %s корректен, режим: %s", p.config, cfg.Modules.Mode)
+	return nil
+}
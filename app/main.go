@@ -1,126 +1,163 @@
 ﻿package main
 
 import (
-	"database/sql"
-	"eclipse/cmd"
+	"context"
 	"eclipse/configs"
 	"eclipse/internal/logger"
-	"eclipse/pkg/services/database"
-	"eclipse/pkg/services/file"
-	"eclipse/pkg/services/telegram"
-	"eclipse/storage"
-	"eclipse/utils/format"
-	"eclipse/utils/managers"
-	"eclipse/utils/shuffle"
+	"eclipse/pkg/interfaces"
+	"errors"
+	"flag"
 	"fmt"
-	"time"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 )
 
-func main() {
-	if err := run(); err != nil {
-		logger.Error(err.Error())
-	}
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, p paths, args []string) error
 }
 
-func run() error {
-	appCfg, err := configs.NewAppConfig()
-	if err != nil {
-		return err
-	}
-
-	if appCfg.IsShuffle {
-		logger.Info("Включен режим перемешивания кошельков")
-
-		err = shuffle.ShuffleFiles("../data/evm_private_keys.txt", "../data/eclipse_private_keys.txt")
-		if err != nil {
-			return err
-		}
-	}
+var commands = []command{
+	{name: "run", usage: "запустить модули на всех аккаунтах (--resume <id>, --retry-failed, --no-wait, --dry-run)", run: runCommand},
+	{name: "validate-config", usage: "проверить конфиг и выйти", run: validateConfigCommand},
+	{name: "balances", usage: "показать балансы Eclipse кошельков", run: balancesCommand},
+	{name: "report", usage: "показать запуски и статистику модулей из базы данных", run: reportCommand},
+	{name: "export", usage: "выгрузить выполненные модули из базы данных (--format csv|json, --out <file>)", run: exportCommand},
+	{name: "generate-wallets", usage: "сгенерировать новые EVM и Eclipse кошельки (--count <n>)", run: generateWalletsCommand},
+}
 
-	wallets, err := storage.LoadWallets("../data/evm_private_keys.txt", "../data/eclipse_private_keys.txt")
-	if err != nil {
-		return err
-	}
+type paths struct {
+	dataDir string
+	config  string
+	words   string
+}
 
-	wordLists, err := file.LoadWordsFromFile("../words/words.txt")
-	if err != nil {
-		logger.Error("Error loading words: %v", err)
-		return err
-	}
+func (p paths) evmKeys() string {
+	return filepath.Join(p.dataDir, "evm_private_keys.txt")
+}
 
-	proxies, err := file.ReadLines("../data/proxies.txt")
-	if err != nil {
-		return err
-	}
+func (p paths) eclipseKeys() string {
+	return filepath.Join(p.dataDir, "eclipse_private_keys.txt")
+}
 
-	if len(proxies) == 0 {
-		return fmt.Errorf("надо указать как минимум одно прокси для работы скрипта")
-	}
+func (p paths) proxies() string {
+	return filepath.Join(p.dataDir, "proxies.txt")
+}
 
-	evmWallets := wallets.EvmAccounts
-	eclipseWallets := wallets.Eclipse
+func (p paths) database() string {
+	return filepath.Join(p.dataDir, "modules.db")
+}
 
-	logger.Info("Успешно подгрузил %d, EVM кошельков, %d, ECLIPSE кошельков, %d прокси", len(evmWallets), len(eclipseWallets), len(proxies))
+func (p paths) tokens() string {
+	return filepath.Join(p.dataDir, "tokens.yaml")
+}
 
-	proxyManager := managers.NewProxyManager(proxies, len(wallets.EvmAccounts))
+func (p paths) loadConfig() (*configs.AppConfig, error) {
+	if err := configs.LoadTokens(p.tokens()); err != nil {
+		return nil, err
+	}
+	return configs.LoadAppConfig(p.config, interfaces.ModuleSpecs())
+}
 
-	logger.Info("Успешно подгрузил конфиг")
-	if appCfg.Threads.Enabled {
-		logger.Info("Включен режим многопоточного запуска аккаунтов")
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
+}
 
-	if appCfg.Telegram.Enabled {
-		logger.Info("Включен режим отправки уведомлений в телеграм")
+func run(args []string) error {
+	fs := flag.NewFlagSet("eclipse", flag.ContinueOnError)
+	dataDir := fs.String("data-dir", "", "папка с ключами, прокси и базой данных (по умолчанию data или ../data рядом с рабочей папкой или программой)")
+	configPath := fs.String("config", "", "путь к конфигу (по умолчанию <data-dir>/config.yaml)")
+	wordsPath := fs.String("words", "", "путь к списку слов (по умолчанию <data-dir>/../words/words.txt)")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Использование: eclipse [флаги] <команда> [флаги команды]\n\nКоманды:\n")
+		for _, c := range commands {
+			fmt.Fprintf(out, "  %-18s %s\n", c.name, c.usage)
+		}
+		fmt.Fprintf(out, "\nФлаги:\n")
+		fs.PrintDefaults()
 	}
 
-	var db *sql.DB
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
 
-	if appCfg.Database.Enabled {
-		logger.Info("Включен режим с использование базы данных")
-		db, err = database.InitDB()
+	if *dataDir == "" {
+		dir, err := findDataDir()
 		if err != nil {
-			logger.Error("Failed to init database: %v", err)
 			return err
 		}
-		defer db.Close()
-		logger.Success("База данных успешно инициализирована")
+		*dataDir = dir
 	}
 
-	notifier, err := telegram.NewNotifier(appCfg.Telegram.BotToken, appCfg.Telegram.UserID)
-	if err != nil {
-		return err
+	p := paths{
+		dataDir: *dataDir,
+		config:  *configPath,
+		words:   *wordsPath,
+	}
+	if p.config == "" {
+		p.config = filepath.Join(p.dataDir, "config.yaml")
+	}
+	if p.words == "" {
+		p.words = filepath.Join(filepath.Dir(filepath.Clean(p.dataDir)), "words", "words.txt")
 	}
 
-	if appCfg.Modules.Mode == "random" {
-		logger.Info("Включен режим рандомного запуска модулей")
-		logger.Info("Включенные модули:")
-		logger.Info("━━━━━━━━━━━━━━━━━━━━━━")
-		logger.Info("• Orca:         %v", format.FormatStatus(appCfg.Modules.Enabled.Orca))
-		logger.Info("• Lifinity:     %v", format.FormatStatus(appCfg.Modules.Enabled.Lifinity))
-		logger.Info("• Invariant:    %v", format.FormatStatus(appCfg.Modules.Enabled.Invariant))
-		logger.Info("• Relay:        %v", format.FormatStatus(appCfg.Modules.Enabled.Relay))
-		logger.Info("• Solar:        %v", format.FormatStatus(appCfg.Modules.Enabled.Solar))
-		logger.Info("• Underdog:     %v", format.FormatStatus(appCfg.Modules.Enabled.Underdog))
-		logger.Info("• Gas Station:  %v", format.FormatStatus(appCfg.Modules.Enabled.GasStation))
-	} else if appCfg.Modules.Mode == "eth" {
-		logger.Info("Включен режим ETH свапов(будут свапы всех балансов в ETH через рандомные свапалки ")
-	} else {
-		logger.Info("Включен режим последовательного запуска модулей")
-		logger.Info("Последовательность выполнения модулей:")
-		logger.Info("━━━━━━━━━━━━━━━━━━━━━━")
-
-		for i, moduleName := range appCfg.Modules.Sequence {
-			logger.Info("• %d. %s", i+1, moduleName)
-		}
+	name := "run"
+	rest := fs.Args()
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
 	}
 
-	logger.Info("━━━━━━━━━━━━━━━━━━━━━━\n")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		// restore default handling so a second Ctrl-C kills the process
+		stop()
+	}()
+
+	for _, c := range commands {
+		if c.name == name {
+			err := c.run(ctx, p, rest)
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return err
+		}
+	}
 
-	logger.Info("Ожидаю 10 секунд для просмотра включенных модулей и начинаю")
+	fs.Usage()
+	return fmt.Errorf("неизвестная команда %q", name)
+}
 
-	time.Sleep(time.Second * 10)
+// findDataDir looks for the data folder in and above the working directory,
+// then next to the executable, so the binary runs from the repo root, from
+// app/ and from any folder it was copied to together with data.
+func findDataDir() (string, error) {
+	candidates := []string{"data", filepath.Join("..", "data")}
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dir := filepath.Dir(exe)
+		candidates = append(candidates, filepath.Join(dir, "data"), filepath.Join(dir, "..", "data"))
+	}
 
-	moduleManager := managers.NewModuleManager(*appCfg.Modules)
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
 
-	return cmd.StartSoft(*wallets, *appCfg, moduleManager, proxyManager, notifier, db, wordLists)
+	return "", fmt.Errorf("папка data не найдена ни рядом с рабочей папкой, ни рядом с программой, укажите --data-dir")
 }
@@ -0,0 +1,138 @@
+﻿package main
+
+import (
+	"context"
+	"database/sql"
+	"eclipse/pkg/services/database"
+	"eclipse/storage"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func reportCommand(ctx context.Context, p paths, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	limit := fs.Int("runs", 10, "сколько последних запусков показать")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	runs, err := database.ListRuns(db, *limit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Запуски:")
+	if len(runs) == 0 {
+		fmt.Println("  нет данных")
+	}
+	for _, run := range runs {
+		stats, err := database.GetRunItemStats(db, run.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  #%d %s %-11s %s  выполнено %d, ошибок %d, не подтверждено %d, осталось %d\n",
+			run.ID, run.CreatedAt, run.Status, run.Mode,
+			stats[database.ItemStatusCompleted], stats[database.ItemStatusFailed],
+			stats[database.ItemStatusUnresolved], stats[database.ItemStatusPending])
+	}
+
+	wallets, err := storage.LoadWallets(p.evmKeys(), p.eclipseKeys())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nМодули по кошелькам:")
+	for i, acc := range wallets.Eclipse {
+		counts, err := database.GetAllModuleCountsForWallet(db, acc.PublicKey.String())
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s %d", name, counts[name]))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "нет")
+		}
+
+		fmt.Printf("  [%d] %s: %s\n", i+1, acc.PublicKey.String(), strings.Join(parts, ", "))
+	}
+
+	return nil
+}
+
+func exportCommand(ctx context.Context, p paths, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "формат выгрузки: csv или json")
+	out := fs.String("out", "", "файл для выгрузки (по умолчанию stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("неизвестный формат %q, доступны: csv, json", *format)
+	}
+
+	db, err := openDatabase(p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	records, err := database.GetModules(db)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "wallet", "dex", "amount", "token", "tx_hash", "created_at"})
+	for _, r := range records {
+		cw.Write([]string{fmt.Sprint(r.ID), r.Wallet, r.Dex, r.Amount, r.Token, r.TxHash, r.CreatedAt})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func openDatabase(p paths) (*sql.DB, error) {
+	if _, err := os.Stat(p.database()); err != nil {
+		return nil, fmt.Errorf("база данных %s не найдена: %v", p.database(), err)
+	}
+
+	return database.InitDB(p.database())
+}
@@ -0,0 +1,225 @@
+﻿package main
+
+import (
+	"context"
+	"database/sql"
+	"eclipse/cmd"
+	"eclipse/internal/logger"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
+	"eclipse/pkg/services/database"
+	"eclipse/pkg/services/file"
+	"eclipse/pkg/services/randomizer"
+	"eclipse/pkg/services/rpcpool"
+	"eclipse/pkg/services/telegram"
+	"eclipse/storage"
+	"eclipse/utils/format"
+	"eclipse/utils/managers"
+	"eclipse/utils/shuffle"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func runCommand(ctx context.Context, p paths, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	resume := fs.Int64("resume", 0, "id запуска, который нужно продолжить")
+	noWait := fs.Bool("no-wait", false, "не ждать 10 секунд перед стартом")
+	dryRun := fs.Bool("dry-run", false, "только симулировать транзакции, ничего не отправлять")
+	retryFailed := fs.Bool("retry-failed", false, "вместе с --resume повторить модули, завершившиеся ошибкой")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *retryFailed && *resume == 0 {
+		return fmt.Errorf("--retry-failed работает только вместе с --resume")
+	}
+
+	appCfg, err := p.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if appCfg.IsShuffle && *resume == 0 {
+		logger.Info("Включен режим перемешивания кошельков")
+
+		err = shuffle.ShuffleFiles(p.evmKeys(), p.eclipseKeys())
+		if err != nil {
+			return err
+		}
+	}
+
+	wallets, err := storage.LoadWallets(p.evmKeys(), p.eclipseKeys())
+	if err != nil {
+		return err
+	}
+
+	wordLists, err := file.LoadWordsFromFile(p.words)
+	if err != nil {
+		logger.Error("Error loading words: %v", err)
+		return err
+	}
+
+	proxies, err := file.ReadLines(p.proxies())
+	if err != nil {
+		return err
+	}
+
+	if len(proxies) == 0 {
+		return fmt.Errorf("надо указать как минимум одно прокси для работы скрипта")
+	}
+
+	evmWallets := wallets.EvmAccounts
+	eclipseWallets := wallets.Eclipse
+
+	logger.Info("Успешно подгрузил %d, EVM кошельков, %d, ECLIPSE кошельков, %d прокси", len(evmWallets), len(eclipseWallets), len(proxies))
+
+	proxyManager := managers.NewProxyManager(proxies, len(wallets.EvmAccounts))
+
+	logger.Info("Успешно подгрузил конфиг")
+	if appCfg.Threads.Enabled {
+		logger.Info("Включен режим многопоточного запуска аккаунтов")
+	}
+
+	if appCfg.Telegram.Enabled {
+		logger.Info("Включен режим отправки уведомлений в телеграм")
+	}
+
+	if *dryRun {
+		if *resume != 0 {
+			return fmt.Errorf("--dry-run нельзя использовать вместе с --resume")
+		}
+		logger.Info("Включен режим dry-run: транзакции только симулируются, в базу и телеграм ничего не пишется")
+		ctx = sender.WithDryRun(ctx)
+	}
+
+	var db *sql.DB
+
+	if appCfg.Database.Enabled {
+		logger.Info("Включен режим с использование базы данных")
+		db, err = database.InitDB(p.database())
+		if err != nil {
+			logger.Error("Failed to init database: %v", err)
+			return err
+		}
+		defer db.Close()
+		logger.Success("База данных успешно инициализирована")
+	}
+
+	var runID int64
+	if *dryRun {
+		// the db stays open for reads such as the Underdog limit
+		appCfg.Database.Enabled = false
+	} else {
+		runID, err = startRun(db, appCfg.Modules.Mode, *resume)
+		if err != nil {
+			return err
+		}
+	}
+
+	notifier := telegram.NewDisabledNotifier()
+	if appCfg.Telegram.Enabled && !*dryRun {
+		notifier, err = telegram.NewNotifier(appCfg.Telegram.BotToken, appCfg.Telegram.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if err := notifier.Flush(); err != nil {
+			logger.Error("Ошибка отправки сообщений: %v", err)
+		}
+	}()
+
+	if appCfg.Modules.Mode == "random" {
+		logger.Info("Включен режим рандомного запуска модулей")
+		logger.Info("Включенные модули:")
+		logger.Info("━━━━━━━━━━━━━━━━━━━━━━")
+		for _, info := range interfaces.RegisteredModules() {
+			logger.Info("• %-14s%v", info.Name+":", format.FormatStatus(appCfg.Modules.Enabled[info.ConfigKey]))
+		}
+	} else if appCfg.Modules.Mode == "eth" {
+		logger.Info("Включен режим ETH свапов(будут свапы всех балансов в ETH через рандомные свапалки ")
+	} else {
+		logger.Info("Включен режим последовательного запуска модулей")
+		logger.Info("Последовательность выполнения модулей:")
+		logger.Info("━━━━━━━━━━━━━━━━━━━━━━")
+
+		for i, moduleName := range appCfg.Modules.Sequence {
+			logger.Info("• %d. %s", i+1, moduleName)
+		}
+	}
+
+	logger.Info("━━━━━━━━━━━━━━━━━━━━━━\n")
+
+	if !*noWait {
+		logger.Info("Ожидаю 10 секунд для просмотра включенных модулей и начинаю")
+
+		if err := randomizer.Sleep(ctx, time.Second*10); err != nil {
+			finishRun(db, runID, database.RunStatusInterrupted)
+			logger.Info("Получен сигнал остановки, выхожу")
+			return nil
+		}
+	}
+
+	moduleManager := managers.NewModuleManager(*appCfg.Modules)
+
+	pool := rpcpool.New(*appCfg.RPC)
+	pool.Start(ctx)
+	ctx = sender.WithWebsocket(ctx, pool.WSEndpoint)
+
+	err = cmd.StartSoft(ctx, runID, *retryFailed, *wallets, *appCfg, pool.Client(), moduleManager, proxyManager, notifier, db, wordLists)
+	if errors.Is(err, context.Canceled) {
+		finishRun(db, runID, database.RunStatusInterrupted)
+		logger.Info("Получен сигнал остановки, текущие транзакции завершены, сохраняю результаты")
+		if runID != 0 {
+			logger.Info("Для продолжения запустите с флагом --resume %d", runID)
+		}
+		return nil
+	}
+
+	finishRun(db, runID, database.RunStatusCompleted)
+
+	return err
+}
+
+func startRun(db *sql.DB, mode string, resume int64) (int64, error) {
+	if db == nil {
+		if resume != 0 {
+			return 0, fmt.Errorf("для --resume нужно включить базу данных в конфиге")
+		}
+		return 0, nil
+	}
+
+	if resume != 0 {
+		run, err := database.GetRun(db, resume)
+		if err != nil {
+			return 0, err
+		}
+		if run.Mode != mode {
+			return 0, fmt.Errorf("запуск #%d был в режиме %s, а в конфиге указан %s", run.ID, run.Mode, mode)
+		}
+		if err := database.SetRunStatus(db, run.ID, database.RunStatusRunning); err != nil {
+			return 0, err
+		}
+		logger.Info("Продолжаю запуск #%d от %s", run.ID, run.CreatedAt)
+		return run.ID, nil
+	}
+
+	runID, err := database.CreateRun(db, mode)
+	if err != nil {
+		return 0, err
+	}
+	logger.Info("Создан запуск #%d", runID)
+
+	return runID, nil
+}
+
+func finishRun(db *sql.DB, runID int64, status string) {
+	if db == nil || runID == 0 {
+		return
+	}
+
+	if err := database.SetRunStatus(db, runID, status); err != nil {
+		logger.Error("Не удалось сохранить статус запуска #%d: %v", runID, err)
+	}
+}
@@ -0,0 +1,89 @@
+﻿package main
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gagliardetto/solana-go"
+)
+
+func generateWalletsCommand(ctx context.Context, p paths, args []string) error {
+	fs := flag.NewFlagSet("generate-wallets", flag.ContinueOnError)
+	count := fs.Int("count", 0, "сколько пар кошельков сгенерировать")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *count <= 0 {
+		return fmt.Errorf("укажите --count больше 0")
+	}
+
+	evmKeys := make([]string, 0, *count)
+	eclipseKeys := make([]string, 0, *count)
+
+	for i := 0; i < *count; i++ {
+		evmKey, err := crypto.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate evm key: %v", err)
+		}
+
+		eclipseKey, err := solana.NewRandomPrivateKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate eclipse key: %v", err)
+		}
+
+		evmKeys = append(evmKeys, hex.EncodeToString(crypto.FromECDSA(evmKey)))
+		eclipseKeys = append(eclipseKeys, eclipseKey.String())
+
+		fmt.Printf("EVM: %s ECLIPSE: %s\n", crypto.PubkeyToAddress(evmKey.PublicKey).Hex(), eclipseKey.PublicKey().String())
+	}
+
+	if err := appendLines(p.evmKeys(), evmKeys); err != nil {
+		return err
+	}
+	if err := appendLines(p.eclipseKeys(), eclipseKeys); err != nil {
+		return err
+	}
+
+	logger.Success("Добавлено %d кошельков в %s и %s", *count, p.evmKeys(), p.eclipseKeys())
+	return nil
+}
+
+func appendLines(path string, lines []string) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// keep the first new key off the last existing line
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			if _, err := f.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return f.Sync()
+}
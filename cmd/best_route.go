@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"eclipse/pkg/interfaces"
+	"eclipse/utils/balance"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+type routeQuote struct {
+	module string
+	quote  interfaces.Quote
+	err    error
+}
+
+// bestSwapModule asks every swap module that can quote for the USDC -> ETH
+// swap of the whole balance and returns the one with the best net output.
+// Falls back to a random swap module when nothing could be quoted.
+func bestSwapModule(ctx context.Context, exec interfaces.ExecutionContext) string {
+	swapModules := interfaces.SwapModules()
+	fallback := swapModules[rand.Intn(len(swapModules))].Name
+
+	amount, err := balance.GetUSDCBalance(ctx, exec.RpcClient, exec.EclipseAccount.PublicKey)
+	if err != nil || amount == 0 {
+		logger.Warning("Не удалось получить баланс USDC для сравнения маршрутов, выбираю %s случайно", fallback)
+		return fallback
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		quotes []routeQuote
+	)
+	for _, info := range swapModules {
+		quoter, ok := info.Module.(interfaces.Quoter)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, quoter interfaces.Quoter) {
+			defer wg.Done()
+
+			quote, err := quoter.QuoteUsdcToEth(ctx, exec, amount)
+
+			mu.Lock()
+			quotes = append(quotes, routeQuote{module: name, quote: quote, err: err})
+			mu.Unlock()
+		}(info.Name, quoter)
+	}
+	wg.Wait()
+
+	sort.Slice(quotes, func(i, j int) bool {
+		if (quotes[i].err == nil) != (quotes[j].err == nil) {
+			return quotes[i].err == nil
+		}
+		return quotes[i].quote.Net() > quotes[j].quote.Net()
+	})
+
+	logger.Info("Котировки USDC -> ETH для %.6f USDC:", float64(amount)/1_000_000)
+	for _, q := range quotes {
+		if q.err != nil {
+			logger.Info("• %-10s ошибка: %v", q.module, q.err)
+			continue
+		}
+		source := ""
+		if q.quote.Simulated {
+			source = ", по симуляции"
+		}
+		logger.Info("• %-10s %.9f ETH (комиссии и рента %.9f ETH%s)", q.module,
+			float64(q.quote.Net())/float64(solana.LAMPORTS_PER_SOL),
+			float64(q.quote.Fee)/float64(solana.LAMPORTS_PER_SOL),
+			source)
+	}
+
+	if len(quotes) == 0 || quotes[0].err != nil {
+		logger.Warning("Ни одна свапалка не дала котировку, выбираю %s случайно", fallback)
+		return fallback
+	}
+
+	logger.Info("Лучший маршрут: %s", quotes[0].module)
+	return quotes[0].module
+}
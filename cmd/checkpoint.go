@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"eclipse/internal/logger"
+	"eclipse/pkg/services/blockchain/sender"
+	"eclipse/pkg/services/database"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// checkpoint persists each account's module plan in run_items so an
+// interrupted run can be resumed. A nil checkpoint keeps the plan in memory.
+type checkpoint struct {
+	db          *sql.DB
+	runID       int64
+	retryFailed bool
+}
+
+func newCheckpoint(db *sql.DB, runID int64, retryFailed bool) *checkpoint {
+	if db == nil || runID == 0 {
+		return nil
+	}
+
+	return &checkpoint{db: db, runID: runID, retryFailed: retryFailed}
+}
+
+// retries reports whether modules that failed earlier in the run are
+// executed again.
+func (c *checkpoint) retries() bool {
+	return c != nil && c.retryFailed
+}
+
+func (c *checkpoint) plan(accountIndex int, wallet string, build func() []string) ([]database.RunItem, error) {
+	if c == nil {
+		modules := build()
+		items := make([]database.RunItem, 0, len(modules))
+		for position, module := range modules {
+			items = append(items, database.RunItem{
+				AccountIndex: accountIndex,
+				Wallet:       wallet,
+				Position:     position,
+				ModuleName:   module,
+				Status:       database.ItemStatusPending,
+			})
+		}
+		return items, nil
+	}
+
+	items, err := database.GetRunItems(c.db, c.runID, wallet)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > 0 {
+		return items, nil
+	}
+
+	return database.SaveRunPlan(c.db, c.runID, accountIndex, wallet, build())
+}
+
+func (c *checkpoint) finish(item database.RunItem, res bool, err error) {
+	if c == nil {
+		return
+	}
+
+	status := database.ItemStatusCompleted
+	errText := ""
+
+	if err != nil && !res {
+		// an interrupted module did not finish, --resume runs it again
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		// one whose transaction may still land must not be retried blindly,
+		// --resume checks its signatures first
+		if errors.Is(err, sender.ErrTransactionPending) {
+			c.unresolved(item, err)
+			return
+		}
+		status = database.ItemStatusFailed
+		errText = err.Error()
+	}
+
+	if err := database.SetRunItemStatus(c.db, item.ID, status, errText); err != nil {
+		logger.Error("Не удалось сохранить прогресс модуля %s: %v", item.ModuleName, err)
+	}
+}
+
+func (c *checkpoint) unresolved(item database.RunItem, err error) {
+	attempts := ""
+	var pending *sender.PendingError
+	if errors.As(err, &pending) {
+		data, jsonErr := json.Marshal(pending.Attempts)
+		if jsonErr != nil {
+			logger.Error("Не удалось сохранить транзакции модуля %s: %v", item.ModuleName, jsonErr)
+		}
+		attempts = string(data)
+	}
+
+	if err := database.SetRunItemUnresolved(c.db, item.ID, err.Error(), attempts); err != nil {
+		logger.Error("Не удалось сохранить прогресс модуля %s: %v", item.ModuleName, err)
+	}
+}
+
+// resolve checks the transactions an unresolved item left behind and
+// reports whether one of them landed. The item is completed when one did
+// and pending again when none can land any more. It fails while a
+// transaction may still land or when there is nothing to check.
+func (c *checkpoint) resolve(ctx context.Context, client *rpc.Client, item database.RunItem) (bool, error) {
+	var attempts []sender.Attempt
+	if item.Attempts != "" {
+		if err := json.Unmarshal([]byte(item.Attempts), &attempts); err != nil {
+			return false, fmt.Errorf("некорректные транзакции модуля в базе: %v", err)
+		}
+	}
+	if len(attempts) == 0 {
+		return false, fmt.Errorf("исход модуля неизвестен и транзакций для проверки нет, проверьте кошелек вручную: %s", item.Error)
+	}
+
+	landed, err := sender.RestoreTracker(client, attempts).Resolve(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	status := database.ItemStatusPending
+	if landed != nil {
+		status = database.ItemStatusCompleted
+	}
+	if err := database.SetRunItemStatus(c.db, item.ID, status, ""); err != nil {
+		return false, err
+	}
+
+	return landed != nil, nil
+}
@@ -7,18 +7,13 @@ import (
 	"eclipse/internal/base"
 	"eclipse/internal/logger"
 	"eclipse/pkg/interfaces"
-	"eclipse/pkg/services/blockchain/gas_station"
-	"eclipse/pkg/services/blockchain/invariant"
-	"eclipse/pkg/services/blockchain/lifinity"
-	"eclipse/pkg/services/blockchain/orca"
-	"eclipse/pkg/services/blockchain/relay"
-	"eclipse/pkg/services/blockchain/solar"
-	"eclipse/pkg/services/blockchain/underdog"
+	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/file"
 	"eclipse/pkg/services/randomizer"
 	"eclipse/pkg/services/telegram"
 	"eclipse/storage"
 	"eclipse/utils/managers"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sync"
@@ -26,277 +21,251 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-func StartSoft(wallets storage.WalletStorage, cfg configs.AppConfig, moduleManager *managers.ModuleManager, proxyManager *managers.ProxyManager, notifier *telegram.Notifier, db *sql.DB, lists *file.WordLists) error {
-	ctx := context.Background()
+func StartSoft(ctx context.Context, runID int64, retryFailed bool, wallets storage.WalletStorage, cfg configs.AppConfig, rpcClient *rpc.Client, moduleManager *managers.ModuleManager, proxyManager *managers.ProxyManager, notifier *telegram.Notifier, db *sql.DB, lists *file.WordLists) error {
+	total := len(wallets.EvmAccounts)
 
-	if !cfg.Threads.Enabled {
-		return processAccountsRange(ctx, 0, 0, len(wallets.EvmAccounts), wallets, cfg, moduleManager, proxyManager, notifier, db, lists)
+	workers := 1
+	if cfg.Threads.Enabled && cfg.Threads.Count > 1 {
+		workers = cfg.Threads.Count
+	}
+	if workers > total {
+		workers = total
 	}
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, cfg.Threads.Count)
-
-	accountsPerThread := len(wallets.EvmAccounts) / cfg.Threads.Count
-	if accountsPerThread == 0 {
-		accountsPerThread = 1
+	jobs := make(chan int, total)
+	for i := 0; i < total; i++ {
+		jobs <- i
 	}
+	close(jobs)
 
-	for i := 0; i < cfg.Threads.Count; i++ {
-		start := i * accountsPerThread
-		end := start + accountsPerThread
-		if i == cfg.Threads.Count-1 {
-			end = len(wallets.EvmAccounts)
-		}
+	results := make([]*AccountResult, total)
+	cp := newCheckpoint(db, runID, retryFailed)
 
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
-		go func(threadNum, start, end int) {
+		go func(worker int) {
 			defer wg.Done()
-			if err := processAccountsRange(ctx, threadNum, start, end, wallets, cfg, moduleManager, proxyManager, notifier, db, lists); err != nil {
-				errChan <- err
+
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+
+				result := processAccount(ctx, worker, i, wallets, cfg, rpcClient, moduleManager, proxyManager, notifier, db, lists, cp)
+				results[i] = result
+
+				if ctx.Err() != nil || result.Resumed || len(jobs) == 0 {
+					continue
+				}
+
+				randomizer.RandomDelay(ctx, cfg.Delay.BetweenAccounts.Min, cfg.Delay.BetweenAccounts.Max, true)
 			}
-		}(i, start, end)
+		}(w)
 	}
 
 	wg.Wait()
-	close(errChan)
 
-	for err := range errChan {
-		if err != nil {
-			return err
-		}
+	fmt.Println()
+	summary := NewRunSummary(results)
+	summary.Print()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d из %d аккаунтов завершились с ошибками", summary.Failed, summary.Total)
 	}
 
 	return nil
 }
 
-func processAccountsRange(ctx context.Context, threadNum, start, end int, wallets storage.WalletStorage, cfg configs.AppConfig, moduleManager *managers.ModuleManager, proxyManager *managers.ProxyManager, notifier *telegram.Notifier, db *sql.DB, lists *file.WordLists) error {
-	var moduleNames []string
-	for name := range moduleManager.EnabledModules {
-		moduleNames = append(moduleNames, name)
+func processAccount(ctx context.Context, worker, i int, wallets storage.WalletStorage, cfg configs.AppConfig, rpcClient *rpc.Client, moduleManager *managers.ModuleManager, proxyManager *managers.ProxyManager, notifier *telegram.Notifier, db *sql.DB, lists *file.WordLists, cp *checkpoint) *AccountResult {
+	httpClient := proxyManager.GetHttpClient(i)
+	eclipseAcc := wallets.Eclipse[i]
+	evmAcc := wallets.EvmAccounts[i]
+
+	result := &AccountResult{
+		Index:   i,
+		Evm:     evmAcc.Address.String(),
+		Eclipse: eclipseAcc.PublicKey.String(),
 	}
 
-	var res bool
-	var err error
+	exec := interfaces.ExecutionContext{
+		HttpClient:     *httpClient,
+		RpcClient:      rpcClient,
+		Config:         cfg,
+		EvmAccount:     evmAcc,
+		EclipseAccount: eclipseAcc,
+		AccountIndex:   i,
+		ProxyManager:   proxyManager,
+		Notifier:       notifier,
+		DB:             db,
+		Words:          lists.Words,
+	}
 
-	for i := start; i < end; i++ {
-		httpClient := proxyManager.GetHttpClient(i)
-		eclipseAcc := wallets.Eclipse[i]
-		evmAcc := wallets.EvmAccounts[i]
+	items, err := cp.plan(i, result.Eclipse, func() []string {
+		return buildPlan(ctx, exec, moduleManager)
+	})
+	if err != nil {
+		logger.Error("[Thread %d] Не удалось загрузить план аккаунта %s: %v", worker+1, result.Eclipse, err)
+		result.Add("Checkpoint", false, err)
+		return result
+	}
 
-		rpcClient := rpc.New("https://mainnetbeta-rpc.eclipse.xyz")
+	var pending []database.RunItem
+	for _, item := range items {
+		switch item.Status {
+		case database.ItemStatusPending:
+			pending = append(pending, item)
+		case database.ItemStatusFailed:
+			if cp.retries() {
+				logger.Info("Модуль %s аккаунта %s ранее завершился ошибкой, повторяю: %s", item.ModuleName, result.Eclipse, item.Error)
+				pending = append(pending, item)
+				continue
+			}
+			result.Add(item.ModuleName, false, errors.New(item.Error))
+		case database.ItemStatusUnresolved:
+			landed, err := cp.resolve(ctx, rpcClient, item)
+			switch {
+			case err != nil:
+				logger.Warning("Модуль %s аккаунта %s не перезапускаю: %v", item.ModuleName, result.Eclipse, err)
+				result.Add(item.ModuleName, false, err)
+			case landed:
+				logger.Success("Транзакция модуля %s аккаунта %s подтвердилась, повтор не нужен", item.ModuleName, result.Eclipse)
+			default:
+				logger.Info("Транзакции модуля %s аккаунта %s не попали в сеть, выполню модуль снова", item.ModuleName, result.Eclipse)
+				pending = append(pending, item)
+			}
+		}
+	}
 
-		notifier.AddMessageForWallet(eclipseAcc.PublicKey.String(),
-			fmt.Sprintf("[%d/%d]\nEVM: %s \nECLIPSE: %s",
-				i+1,
-				len(wallets.EvmAccounts),
-				evmAcc.Address.String(),
-				eclipseAcc.PublicKey.String(),
-			),
-		)
+	if len(pending) == 0 && len(items) > 0 {
+		logger.Info("[Thread %d] Account [%d/%d] ECLIPSE: %s уже отработан в этом запуске, пропускаю",
+			worker+1, i+1, len(wallets.EvmAccounts), result.Eclipse)
+		result.Resumed = true
+		return result
+	}
 
-		logger.Info("[Thread %d] Account [%d/%d] start EVM: %s, ECLIPSE: %s\n\n",
-			threadNum+1,
+	notifier.AddMessageForWallet(eclipseAcc.PublicKey.String(),
+		fmt.Sprintf("[%d/%d]\nEVM: %s \nECLIPSE: %s",
 			i+1,
 			len(wallets.EvmAccounts),
 			evmAcc.Address.String(),
 			eclipseAcc.PublicKey.String(),
-		)
+		),
+	)
+
+	logger.Info("[Thread %d] Account [%d/%d] start EVM: %s, ECLIPSE: %s\n\n",
+		worker+1,
+		i+1,
+		len(wallets.EvmAccounts),
+		evmAcc.Address.String(),
+		eclipseAcc.PublicKey.String(),
+	)
+
+	if len(pending) < len(items) {
+		logger.Info("Продолжаю аккаунт %s: осталось %d из %d модулей\n", eclipseAcc.PublicKey.String(), len(pending), len(items))
+	} else {
+		logger.Info("Буду выполнять %d модулей на аккаунте %s\n", len(pending), eclipseAcc.PublicKey.String())
+	}
 
-		if cfg.Modules.Mode == "random" && cfg.Modules.Enabled.Relay {
-			relayModule := relay.Module{}
-			res, err = relayModule.Execute(
-				ctx,
-				*cfg.Relay,
-				evmAcc,
-				eclipseAcc,
-				rpcClient,
-				*httpClient,
-				notifier,
-				db,
-				cfg.Delay.BetweenRetries.Attempts,
-			)
-
-			if err != nil {
-				logger.Error("[Thread %d] Error: %v", threadNum+1, err)
-			}
+	for moduleIndex, item := range pending {
+		moduleName := item.ModuleName
 
-			if err == nil || res {
-				randomizer.RandomDelay(cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, true)
-			} else if err != nil {
-				randomizer.RandomDelay(cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, false)
-			}
+		if ctx.Err() != nil {
+			logger.Info("[Thread %d] Получен сигнал остановки, пропускаю оставшиеся модули", worker+1)
+			result.Interrupted = true
+			break
 		}
 
 		fmt.Println()
+		logger.Info("Выполняю модуль %d/%d: %s", moduleIndex+1, len(pending), moduleName)
+
+		moduleInfo, exists := interfaces.GetModule(moduleName)
+		if !exists {
+			logger.Error("Неизвестный модуль: %s", moduleName)
+			err := fmt.Errorf("неизвестный модуль")
+			result.Add(moduleName, false, err)
+			cp.finish(item, false, err)
+			continue
+		}
 
-		var modulesToExecute []string
-		if cfg.Modules.Mode == "random" {
-			availableModules := make([]string, 0)
-			for name := range moduleManager.EnabledModules {
-				if name != "Relay" {
-					availableModules = append(availableModules, name)
-				}
-			}
-
-			numModules := base.GetRandomPrecision(
-				cfg.Modules.ModulesCount.Min,
-				cfg.Modules.ModulesCount.Max,
-			)
-
-			for j := 0; j < numModules; j++ {
-				randomIndex := rand.Intn(len(availableModules))
-				modulesToExecute = append(modulesToExecute, availableModules[randomIndex])
-			}
-
-			logger.Info("Буду выполнять %d модулей на аккаунте %s\n", numModules, eclipseAcc.PublicKey.String())
-		} else if cfg.Modules.Mode == "queue" {
-			modulesToExecute = cfg.Modules.Sequence
-			logger.Info("Буду выполнять %d модулей на аккаунте %s\n", len(modulesToExecute), eclipseAcc.PublicKey.String())
-		} else if cfg.Modules.Mode == "eth" {
-			swapModules := []string{"Orca", "Solar", "Invariant", "Lifinity"}
-			randomModule := swapModules[rand.Intn(len(swapModules))]
-			modulesToExecute = []string{randomModule}
-			logger.Info("Буду выполнять %d модулей на аккаунте %s\n", len(modulesToExecute), eclipseAcc.PublicKey.String())
+		if cfg.Modules.Mode == "eth" {
+			logger.Info("Режим ETH: используем %s для свапа USDC -> ETH", moduleName)
 		}
 
-		for moduleIndex, moduleName := range modulesToExecute {
-			fmt.Println()
-			logger.Info("Выполняю модуль %d/%d: %s", moduleIndex+1, len(modulesToExecute), moduleName)
+		res, err := moduleInfo.Module.Execute(ctx, exec)
+		result.Add(moduleName, res, err)
+		cp.finish(item, res, err)
 
-			var moduleInfo interfaces.ModuleInfo
-			if cfg.Modules.Mode == "random" {
-				var exists bool
-				moduleInfo, exists = moduleManager.EnabledModules[moduleName]
-				if !exists {
-					logger.Error("Модуль %s не найден", moduleName)
-					continue
-				}
-			} else if cfg.Modules.Mode == "queue" {
-				switch moduleName {
-				case "Orca":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &orca.Module{},
-						Type:   interfaces.OrcaType,
-					}
-				case "Underdog":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &underdog.Module{},
-						Type:   interfaces.UnderdogType,
-					}
-				case "Invariant":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &invariant.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				case "Relay":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &relay.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				case "Lifinity":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &lifinity.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				case "Solar":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &solar.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				case "Gas Station":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &gas_station.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				default:
-					logger.Error("Неизвестный модуль: %s", moduleName)
-					continue
-				}
-			} else if cfg.Modules.Mode == "eth" {
-				switch moduleName {
-				case "Orca":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &orca.Module{},
-						Type:   interfaces.OrcaType,
-					}
-				case "Solar":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &solar.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				case "Invariant":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &invariant.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				case "Lifinity":
-					moduleInfo = interfaces.ModuleInfo{
-						Module: &lifinity.Module{},
-						Type:   interfaces.DefaultType,
-					}
-				}
-
-				logger.Info("Режим ETH: используем %s для свапа USDC -> ETH", moduleName)
+		if moduleIndex == len(pending)-1 {
+			randomizer.RandomDelay(ctx, cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, false)
+		} else if err == nil || res {
+			randomizer.RandomDelay(ctx, cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, true)
+		} else {
+			randomizer.RandomDelay(ctx, cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, false)
+		}
+	}
 
-				if err != nil {
-					logger.Error("[Thread %d] Error during ETH swap: %v", threadNum+1, err)
-				}
-			}
+	err = notifier.SendWalletMessages(eclipseAcc.PublicKey.String())
+	if err != nil {
+		logger.Error("Ошибка отправки сообщений: %v", err)
+	} else {
+		logger.Info("Сообщения успешно отправлены в телеграм")
+	}
 
-			switch moduleInfo.Type {
-			case interfaces.OrcaType:
-				module := moduleInfo.Module.(interfaces.OrcaModule)
-				res, err = module.Execute(ctx, rpcClient, cfg, eclipseAcc, proxyManager, notifier, db, i, cfg.Delay.BetweenRetries.Attempts)
+	if result.Success() {
+		logger.Info("[Thread %d] Accounts [%d/%d] EVM: %s, ECLIPSE: %s successfully ended\n\n",
+			worker+1,
+			i+1, len(wallets.EvmAccounts),
+			result.Evm,
+			result.Eclipse,
+		)
+	} else {
+		logger.Info("[Thread %d] Accounts [%d/%d] EVM: %s, ECLIPSE: %s ended with errors\n\n",
+			worker+1,
+			i+1, len(wallets.EvmAccounts),
+			result.Evm,
+			result.Eclipse,
+		)
+	}
 
-			case interfaces.UnderdogType:
-				module := moduleInfo.Module.(interfaces.UnderdogModule)
-				res, err = module.Execute(ctx, *httpClient, rpcClient, cfg, eclipseAcc, notifier, db, lists.Words, cfg.MinEthHold, cfg.Delay.BetweenRetries.Attempts)
+	return result
+}
 
-			case interfaces.DefaultType:
-				module := moduleInfo.Module.(interfaces.DefaultModule)
-				res, err = module.Execute(ctx, *httpClient, rpcClient, cfg, eclipseAcc, notifier, db, cfg.Delay.BetweenRetries.Attempts)
-			}
+func buildPlan(ctx context.Context, exec interfaces.ExecutionContext, moduleManager *managers.ModuleManager) []string {
+	cfg := exec.Config
+	var modules []string
 
-			if moduleIndex == len(modulesToExecute)-1 {
-				randomizer.RandomDelay(cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, false)
-			} else if err == nil || res {
-				randomizer.RandomDelay(cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, true)
-			} else {
-				randomizer.RandomDelay(cfg.Delay.BetweenModules.Min, cfg.Delay.BetweenModules.Max, false)
-			}
+	switch cfg.Modules.Mode {
+	case configs.ModeRandom:
+		if _, exists := moduleManager.EnabledModules["Relay"]; exists {
+			modules = append(modules, "Relay")
 		}
 
-		err = notifier.SendWalletMessages(eclipseAcc.PublicKey.String())
-		if err != nil {
-			logger.Error("Ошибка отправки сообщений: %v", err)
-		} else {
-			logger.Info("Сообщения успешно отправлены в телеграм")
+		availableModules := make([]string, 0)
+		for name := range moduleManager.EnabledModules {
+			if name != "Relay" {
+				availableModules = append(availableModules, name)
+			}
 		}
 
-		if i+1 == len(wallets.Eclipse) {
-			fmt.Println()
-			logger.Info("Все аккаунты отработаны")
-			return nil
-		}
+		numModules := base.GetRandomPrecision(
+			cfg.Modules.ModulesCount.Min,
+			cfg.Modules.ModulesCount.Max,
+		)
 
-		if res {
-			logger.Info("[Thread %d] Accounts [%d/%d] EVM: %s, ECLIPSE: %s successfully ended\n\n",
-				threadNum+1,
-				i+1, len(wallets.EvmAccounts),
-				wallets.EvmAccounts[i].Address.String(),
-				wallets.Eclipse[i].PublicKey.String(),
-			)
-			randomizer.RandomDelay(cfg.Delay.BetweenAccounts.Min, cfg.Delay.BetweenAccounts.Max, true)
-		} else {
-			logger.Info("[Thread %d] Accounts [%d/%d] EVM: %s, ECLIPSE: %s ended with errors\n\n",
-				threadNum+1,
-				i+1, len(wallets.EvmAccounts),
-				wallets.EvmAccounts[i].Address.String(),
-				wallets.Eclipse[i].PublicKey.String(),
-			)
-			randomizer.RandomDelay(cfg.Delay.BetweenAccounts.Min, cfg.Delay.BetweenAccounts.Max, true)
+		for j := 0; j < numModules && len(availableModules) > 0; j++ {
+			randomIndex := rand.Intn(len(availableModules))
+			modules = append(modules, availableModules[randomIndex])
 		}
+	case configs.ModeSequence:
+		modules = append(modules, cfg.Modules.Sequence...)
+	case configs.ModeEth:
+		modules = []string{bestSwapModule(ctx, exec)}
 	}
 
-	return nil
+	return modules
 }
@@ -0,0 +1,11 @@
+package cmd
+
+import (
+	_ "eclipse/pkg/services/blockchain/gas_station"
+	_ "eclipse/pkg/services/blockchain/invariant"
+	_ "eclipse/pkg/services/blockchain/lifinity"
+	_ "eclipse/pkg/services/blockchain/orca"
+	_ "eclipse/pkg/services/blockchain/relay"
+	_ "eclipse/pkg/services/blockchain/solar"
+	_ "eclipse/pkg/services/blockchain/underdog"
+)
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"errors"
+)
+
+type ModuleFailure struct {
+	Module string
+	Err    error
+}
+
+type AccountResult struct {
+	Index       int
+	Evm         string
+	Eclipse     string
+	Failures    []ModuleFailure
+	Interrupted bool
+	Resumed     bool
+}
+
+func (r *AccountResult) Add(module string, res bool, err error) {
+	if err == nil || res {
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		r.Interrupted = true
+		return
+	}
+
+	r.Failures = append(r.Failures, ModuleFailure{Module: module, Err: err})
+}
+
+func (r *AccountResult) Success() bool {
+	return len(r.Failures) == 0 && !r.Interrupted
+}
+
+type RunSummary struct {
+	Total       int
+	Succeeded   int
+	Failed      int
+	Interrupted int
+	Skipped     int
+	Results     []*AccountResult
+}
+
+func NewRunSummary(results []*AccountResult) RunSummary {
+	summary := RunSummary{
+		Total:   len(results),
+		Results: results,
+	}
+
+	for _, result := range results {
+		switch {
+		case result == nil:
+			summary.Skipped++
+		case len(result.Failures) > 0:
+			summary.Failed++
+		case result.Interrupted:
+			summary.Interrupted++
+		default:
+			summary.Succeeded++
+		}
+	}
+
+	return summary
+}
+
+func (s RunSummary) Print() {
+	logger.Info("━━━━━━━━━━━━━━━━━━━━━━")
+	logger.Info("Итоги запуска: всего %d, успешно %d, с ошибками %d, прервано %d, не запущено %d",
+		s.Total, s.Succeeded, s.Failed, s.Interrupted, s.Skipped)
+
+	for _, result := range s.Results {
+		if result == nil || len(result.Failures) == 0 {
+			continue
+		}
+
+		logger.Error("[%d/%d] EVM: %s, ECLIPSE: %s", result.Index+1, s.Total, result.Evm, result.Eclipse)
+		for _, failure := range result.Failures {
+			logger.Error("  • %s: %v", failure.Module, failure.Err)
+		}
+	}
+
+	if s.Failed == 0 && s.Interrupted == 0 && s.Skipped == 0 {
+		logger.Info("Все аккаунты отработаны")
+	}
+
+	logger.Info("━━━━━━━━━━━━━━━━━━━━━━")
+}
@@ -1,19 +1,16 @@
 ﻿package configs
 
 import (
-	"eclipse/constants"
-	"github.com/gagliardetto/solana-go"
-	"gopkg.in/yaml.v3"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
-)
+	"slices"
+	"strings"
 
-type Config struct {
-	Orca struct {
-		Tokens []string     `yaml:"tokens"`
-		Native NativeConfig `yaml:"native"`
-		Stable AmountConfig `yaml:"stable"`
-	} `yaml:"swaps"`
-}
+	"gopkg.in/yaml.v3"
+)
 
 type ThreadConfig struct {
 	Count   int  `yaml:"count"`
@@ -27,20 +24,24 @@ type DatabaseConfig struct {
 type AppConfig struct {
 	Orca       *OrcaConfig
 	Invariant  *InvariantConfig
+	Lifinity   *LifinityConfig
 	Relay      *RelayConfig
 	Delay      *DelayConfig
 	Modules    *ModulesConfig
-	Threads    ThreadConfig `yaml:"threads"`
+	Threads    ThreadConfig
 	Telegram   *TelegramConfig
-	IsShuffle  bool            `yaml:"is_shuffle"`
-	MinEthHold float64         `yaml:"min_eth_hold"`
-	Database   *DatabaseConfig `yaml:"database"`
+	IsShuffle  bool
+	MinEthHold float64
+	Database   *DatabaseConfig
+	RPC        *RPCConfig
+	Fees       *FeesConfig
+	Slippage   *SlippageConfig
 }
 
-type Token struct {
-	Symbol   string
-	Address  solana.PublicKey
-	Decimals int8
+type SwapsConfig struct {
+	Tokens []string     `yaml:"tokens"`
+	Native NativeConfig `yaml:"native"`
+	Stable AmountConfig `yaml:"stable"`
 }
 
 type NativeConfig struct {
@@ -55,77 +56,178 @@ type AmountConfig struct {
 	MaxPrecision int     `yaml:"max_precision"`
 }
 
-func NewAppConfig() (*AppConfig, error) {
-	orcaConfig, err := NewOrcaConfig()
+// ModuleSpec describes a registered module so the loader can check
+// modules.enabled and modules.sequence without importing the registry.
+type ModuleSpec struct {
+	Name      string
+	ConfigKey string
+}
+
+type fileConfig struct {
+	EthBridge  EthBridgeConfig `yaml:"eth_bridge"`
+	Networks   NetworkConfig   `yaml:"networks"`
+	Withdraw   WithdrawConfig  `yaml:"relay_withdraw"`
+	MinEthHold float64         `yaml:"min_eth_hold"`
+	Swaps      SwapsConfig     `yaml:"swaps"`
+	Delay      DelayConfig     `yaml:"delay"`
+	Modules    ModulesConfig   `yaml:"modules"`
+	Threads    ThreadConfig    `yaml:"threads"`
+	IsShuffle  bool            `yaml:"is_shuffle"`
+	Telegram   TelegramConfig  `yaml:"telegram"`
+	Database   DatabaseConfig  `yaml:"database"`
+	RPC        RPCConfig       `yaml:"rpc"`
+	Fees       FeesConfig      `yaml:"fees"`
+	Slippage   SlippageConfig  `yaml:"slippage"`
+}
+
+func defaultFileConfig() fileConfig {
+	var cfg fileConfig
+
+	cfg.Delay.BetweenAccounts = DelayRange{Min: 15, Max: 30}
+	cfg.Delay.BetweenModules = DelayRange{Min: 1, Max: 2}
+	cfg.Delay.BetweenRetries = RetryConfig{Min: 5, Max: 15, Attempts: 10}
+	cfg.Modules.Mode = ModeRandom
+	cfg.Modules.ModulesCount = ModulesCountConfig{Min: 1, Max: 1}
+	cfg.Modules.Limited.Underdog = 1
+	cfg.Threads.Count = 1
+	cfg.RPC = defaultRPCConfig()
+	cfg.Fees = defaultFeesConfig()
+	cfg.Slippage = defaultSlippageConfig()
+
+	return cfg
+}
+
+func LoadAppConfig(path string, modules []ModuleSpec) (*AppConfig, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error reading config: %v", err)
 	}
 
-	invariantConfig, err := NewInvariantConfig()
-	if err != nil {
-		return nil, err
+	cfg := defaultFileConfig()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("конфиг %s пуст", path)
+		}
+		return nil, fmt.Errorf("ошибка разбора конфига %s: %v", path, err)
 	}
 
-	relayConfig, err := NewRelayConfig()
-	if err != nil {
-		return nil, err
+	if mode, ok := modeAliases[cfg.Modules.Mode]; ok {
+		cfg.Modules.Mode = mode
 	}
 
-	delayConfig, err := NewDelayConfig()
-	if err != nil {
-		return nil, err
+	if errs := cfg.validate(modules); len(errs) > 0 {
+		return nil, fmt.Errorf("некорректный конфиг %s:\n%w", path, errors.Join(errs...))
 	}
 
-	modulesConfig, err := NewModulesConfig()
-	if err != nil {
-		return nil, err
+	return &AppConfig{
+		Orca:       newOrcaConfig(cfg.Swaps),
+		Invariant:  newInvariantConfig(cfg.Swaps),
+		Lifinity:   newLifinityConfig(cfg.Swaps),
+		Relay:      &RelayConfig{EthBridge: cfg.EthBridge, Networks: cfg.Networks, Withdraw: cfg.Withdraw},
+		Delay:      &cfg.Delay,
+		Modules:    &cfg.Modules,
+		Threads:    cfg.Threads,
+		Telegram:   &cfg.Telegram,
+		IsShuffle:  cfg.IsShuffle,
+		MinEthHold: cfg.MinEthHold,
+		Database:   &cfg.Database,
+		RPC:        &cfg.RPC,
+		Fees:       &cfg.Fees,
+		Slippage:   &cfg.Slippage,
+	}, nil
+}
+
+func (c *fileConfig) validate(modules []ModuleSpec) []error {
+	var errs []error
+
+	checkAmount := func(name string, amount AmountConfig) {
+		errs = append(errs, checkRange(name+".min_value/max_value", amount.MinValue, amount.MaxValue)...)
+		errs = append(errs, checkRange(name+".min_precision/max_precision", float64(amount.MinPrecision), float64(amount.MaxPrecision))...)
 	}
 
-	telegramConfig, err := NewTelegramConfig()
-	if err != nil {
-		return nil, err
+	checkAmount("eth_bridge", AmountConfig{
+		MinValue:     c.EthBridge.MinValue,
+		MaxValue:     c.EthBridge.MaxValue,
+		MinPrecision: c.EthBridge.MinPrecision,
+		MaxPrecision: c.EthBridge.MaxPrecision,
+	})
+	checkAmount("relay_withdraw.eth", c.Withdraw.ETH)
+	checkAmount("relay_withdraw.usdc", c.Withdraw.USDC)
+	checkAmount("swaps.native.eth", c.Swaps.Native.ETH)
+	checkAmount("swaps.native.sol", c.Swaps.Native.SOL)
+	checkAmount("swaps.stable", c.Swaps.Stable)
+
+	errs = append(errs, checkRange("delay.between_accounts", c.Delay.BetweenAccounts.Min, c.Delay.BetweenAccounts.Max)...)
+	errs = append(errs, checkRange("delay.between_modules", c.Delay.BetweenModules.Min, c.Delay.BetweenModules.Max)...)
+	errs = append(errs, checkRange("delay.between_retries", c.Delay.BetweenRetries.Min, c.Delay.BetweenRetries.Max)...)
+	if c.Delay.BetweenRetries.Attempts < 1 {
+		errs = append(errs, fmt.Errorf("delay.between_retries.attempts: должно быть не меньше 1, указано %d", c.Delay.BetweenRetries.Attempts))
 	}
 
-	data, err := os.ReadFile(constants.ConfigPath)
-	if err != nil {
-		return nil, err
+	if c.MinEthHold < 0 {
+		errs = append(errs, fmt.Errorf("min_eth_hold: не может быть отрицательным"))
 	}
 
-	var wrapper struct {
-		Threads    ThreadConfig   `yaml:"threads"`
-		IsShuffle  bool           `yaml:"is_shuffle"`
-		MinEthHold float64        `yaml:"min_eth_hold"`
-		Database   DatabaseConfig `yaml:"database"`
+	if len(c.Swaps.Tokens) < 2 {
+		errs = append(errs, fmt.Errorf("swaps.tokens: нужно указать минимум два токена, указано %d", len(c.Swaps.Tokens)))
 	}
-	if err := yaml.Unmarshal(data, &wrapper); err != nil {
-		return nil, err
+	for _, symbol := range c.Swaps.Tokens {
+		if _, exists := LookupToken(symbol); !exists {
+			errs = append(errs, fmt.Errorf("swaps.tokens: неизвестный токен %q, доступны: %s", symbol, strings.Join(tokenSymbols(), ", ")))
+		}
 	}
 
-	return &AppConfig{
-		Orca:       orcaConfig,
-		Invariant:  invariantConfig,
-		Relay:      relayConfig,
-		Delay:      delayConfig,
-		Modules:    modulesConfig,
-		Threads:    wrapper.Threads,
-		Telegram:   telegramConfig,
-		IsShuffle:  wrapper.IsShuffle,
-		MinEthHold: wrapper.MinEthHold,
-		Database:   &wrapper.Database,
-	}, nil
-}
+	for _, name := range c.Networks.Chains {
+		if GetChainByName(name) == nil {
+			errs = append(errs, fmt.Errorf("networks.chains: неизвестная сеть %q", name))
+		}
+	}
 
-func LoadConfig() (*Config, error) {
-	f, err := os.ReadFile(constants.ConfigPath)
-	if err != nil {
-		return nil, err
+	for _, name := range c.Withdraw.Chains {
+		chain := GetChainByName(name)
+		if chain == nil || chain.ChainID == EclipseChain.ChainID {
+			errs = append(errs, fmt.Errorf("relay_withdraw.chains: неизвестная сеть %q", name))
+		}
+	}
+	for _, symbol := range c.Withdraw.Tokens {
+		if !slices.Contains(WithdrawTokens, symbol) {
+			errs = append(errs, fmt.Errorf("relay_withdraw.tokens: неизвестный токен %q, доступны: %s", symbol, strings.Join(WithdrawTokens, ", ")))
+		}
 	}
 
-	var cfg Config
-	err = yaml.Unmarshal(f, &cfg)
-	if err != nil {
-		return nil, err
+	errs = append(errs, c.Modules.validate(modules)...)
+	errs = append(errs, c.RPC.validate()...)
+	errs = append(errs, c.Fees.validate()...)
+	errs = append(errs, c.Slippage.validate()...)
+
+	if c.Threads.Enabled && c.Threads.Count < 1 {
+		errs = append(errs, fmt.Errorf("threads.count: должно быть не меньше 1, указано %d", c.Threads.Count))
+	}
+
+	if c.Telegram.Enabled {
+		if c.Telegram.BotToken == "" {
+			errs = append(errs, fmt.Errorf("telegram.bot_token: обязателен, когда telegram.enabled: true"))
+		}
+		if c.Telegram.UserID == 0 {
+			errs = append(errs, fmt.Errorf("telegram.user_id: обязателен, когда telegram.enabled: true"))
+		}
+	}
+
+	return errs
+}
+
+func checkRange(name string, min, max float64) []error {
+	var errs []error
+
+	if min < 0 || max < 0 {
+		errs = append(errs, fmt.Errorf("%s: значения не могут быть отрицательными", name))
+	}
+	if min > max {
+		errs = append(errs, fmt.Errorf("%s: min (%v) больше max (%v)", name, min, max))
 	}
 
-	return &cfg, nil
+	return errs
 }
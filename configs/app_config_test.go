@@ -0,0 +1,196 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testModules = []ModuleSpec{
+	{Name: "Orca", ConfigKey: "orca"},
+	{Name: "Relay", ConfigKey: "relay"},
+}
+
+const minimalConfig = `
+swaps:
+  tokens: [ETH, USDC]
+modules:
+  enabled:
+    orca: true
+`
+
+func TestMain(m *testing.M) {
+	if err := LoadTokens(filepath.Join("..", "data", "tokens.yaml")); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAppConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr []string
+		check   func(t *testing.T, cfg *AppConfig)
+	}{
+		{
+			name:    "defaults",
+			content: minimalConfig,
+			check: func(t *testing.T, cfg *AppConfig) {
+				if cfg.Modules.Mode != ModeRandom {
+					t.Errorf("mode = %q, want %q", cfg.Modules.Mode, ModeRandom)
+				}
+				if cfg.Fees.Percentile != 75 || cfg.Fees.MaxMicroLamports != 200000 {
+					t.Errorf("fees = %+v, want defaults", *cfg.Fees)
+				}
+				if cfg.Slippage.Orca != 50 || cfg.Slippage.GasStation != 100 {
+					t.Errorf("slippage = %+v, want defaults", *cfg.Slippage)
+				}
+				if len(cfg.RPC.Eclipse) != 1 || cfg.RPC.Eclipse[0].HTTP != EclipseChain.RPC {
+					t.Errorf("rpc = %+v, want the public Eclipse RPC", cfg.RPC.Eclipse)
+				}
+				if len(cfg.Orca.Tokens) != 2 {
+					t.Errorf("orca tokens = %d, want 2", len(cfg.Orca.Tokens))
+				}
+			},
+		},
+		{
+			name:    "byte order mark",
+			content: "\xef\xbb\xbf" + minimalConfig,
+		},
+		{
+			name: "queue alias",
+			content: minimalConfig + `  mode: queue
+  sequence: [Orca]
+`,
+			check: func(t *testing.T, cfg *AppConfig) {
+				if cfg.Modules.Mode != ModeSequence {
+					t.Errorf("mode = %q, want %q", cfg.Modules.Mode, ModeSequence)
+				}
+			},
+		},
+		{
+			name:    "empty",
+			content: "",
+			wantErr: []string{"пуст"},
+		},
+		{
+			name:    "unknown field",
+			content: minimalConfig + "unknown_key: 1\n",
+			wantErr: []string{"ошибка разбора", "unknown_key"},
+		},
+		{
+			name: "unknown token",
+			content: `
+swaps:
+  tokens: [ETH, DOGE]
+modules:
+  enabled:
+    orca: true
+`,
+			wantErr: []string{`swaps.tokens: неизвестный токен "DOGE"`},
+		},
+		{
+			name: "reversed range",
+			content: minimalConfig + `  modules_count:
+    min: 3
+    max: 1
+`,
+			wantErr: []string{"modules.modules_count"},
+		},
+		{
+			name: "unknown module",
+			content: minimalConfig + `    lifinity: true
+`,
+			wantErr: []string{`modules.enabled: неизвестный модуль "lifinity"`},
+		},
+		{
+			name: "withdraw to eclipse",
+			content: minimalConfig + `relay_withdraw:
+  chains: [Eclipse]
+`,
+			wantErr: []string{`relay_withdraw.chains: неизвестная сеть "Eclipse"`},
+		},
+		{
+			name: "fees and slippage out of range",
+			content: minimalConfig + `fees:
+  percentile: 101
+  min_micro_lamports: 10
+  max_micro_lamports: 5
+slippage:
+  orca: 0
+`,
+			wantErr: []string{
+				"fees.percentile",
+				"fees: min_micro_lamports (10) больше max_micro_lamports (5)",
+				"slippage.orca",
+			},
+		},
+		{
+			name: "telegram without credentials",
+			content: minimalConfig + `telegram:
+  enabled: true
+`,
+			wantErr: []string{"telegram.bot_token", "telegram.user_id"},
+		},
+		{
+			name: "rpc without endpoints",
+			content: minimalConfig + `rpc:
+  eclipse: []
+`,
+			wantErr: []string{"rpc.eclipse: нужно указать хотя бы один RPC"},
+		},
+		{
+			name: "rpc with an http websocket",
+			content: minimalConfig + `rpc:
+  eclipse:
+    - http: "https://mainnetbeta-rpc.eclipse.xyz"
+      ws: "https://mainnetbeta-rpc.eclipse.xyz"
+`,
+			wantErr: []string{"rpc.eclipse[0].ws: ожидается ws(s) адрес"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadAppConfig(writeConfig(t, tt.content), testModules)
+
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("expected an error containing %q", tt.wantErr)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not contain %q", err, want)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadAppConfigMissingFile(t *testing.T) {
+	_, err := LoadAppConfig(filepath.Join(t.TempDir(), "missing.yaml"), testModules)
+	if err == nil || !strings.Contains(err.Error(), "error reading config") {
+		t.Fatalf("err = %v, want a read error", err)
+	}
+}
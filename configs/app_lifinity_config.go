@@ -0,0 +1,15 @@
+﻿package configs
+
+type LifinityConfig struct {
+	Tokens []Token
+	Native NativeConfig
+	Stable AmountConfig
+}
+
+func newLifinityConfig(swaps SwapsConfig) *LifinityConfig {
+	return &LifinityConfig{
+		Tokens: tradableTokens(swaps.Tokens, "lifinity"),
+		Native: swaps.Native,
+		Stable: swaps.Stable,
+	}
+}
@@ -1,51 +1,90 @@
 ﻿package configs
 
 import (
-	"eclipse/constants"
 	"fmt"
-	"os"
+	"sort"
+	"strings"
+)
 
-	"gopkg.in/yaml.v3"
+const (
+	ModeRandom   = "random"
+	ModeSequence = "sequence"
+	ModeEth      = "eth"
 )
 
+// "queue" is the name older builds used for sequence mode.
+var modeAliases = map[string]string{
+	"queue": ModeSequence,
+}
+
 type ModulesCountConfig struct {
 	Min int `yaml:"min"`
 	Max int `yaml:"max"`
 }
 
-type EnabledModulesConfig struct {
-	Orca       bool `yaml:"orca"`
-	Lifinity   bool `yaml:"lifinity"`
-	Invariant  bool `yaml:"invariant"`
-	Relay      bool `yaml:"relay"`
-	Solar      bool `yaml:"solar"`
-	Underdog   bool `yaml:"underdog"`
-	GasStation bool `yaml:"gas_station"`
-}
-
 type ModulesConfig struct {
-	ModulesCount ModulesCountConfig   `yaml:"modules_count"`
-	Mode         string               `yaml:"mode"`
-	Sequence     []string             `yaml:"sequence"`
-	Enabled      EnabledModulesConfig `yaml:"enabled"`
+	ModulesCount ModulesCountConfig `yaml:"modules_count"`
+	Mode         string             `yaml:"mode"`
+	Sequence     []string           `yaml:"sequence"`
+	Enabled      map[string]bool    `yaml:"enabled"`
 	Limited      struct {
 		Underdog int `yaml:"underdog"`
 	} `yaml:"limited"`
 }
 
-func NewModulesConfig() (*ModulesConfig, error) {
-	data, err := os.ReadFile(constants.ConfigPath)
-	if err != nil {
-		return nil, fmt.Errorf("error reading modules_config: %v", err)
+func (c *ModulesConfig) validate(modules []ModuleSpec) []error {
+	var errs []error
+
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+	var nameList, keyList []string
+	for _, module := range modules {
+		names[module.Name] = true
+		keys[module.ConfigKey] = true
+		nameList = append(nameList, module.Name)
+		keyList = append(keyList, module.ConfigKey)
+	}
+	sort.Strings(keyList)
+
+	enabledKeys := make([]string, 0, len(c.Enabled))
+	for key := range c.Enabled {
+		enabledKeys = append(enabledKeys, key)
+	}
+	sort.Strings(enabledKeys)
+
+	enabled := 0
+	for _, key := range enabledKeys {
+		if !keys[key] {
+			errs = append(errs, fmt.Errorf("modules.enabled: неизвестный модуль %q, доступны: %s", key, strings.Join(keyList, ", ")))
+			continue
+		}
+		if c.Enabled[key] && key != "relay" {
+			enabled++
+		}
 	}
 
-	var wrapper struct {
-		Modules ModulesConfig `yaml:"modules"`
+	for _, name := range c.Sequence {
+		if !names[name] {
+			errs = append(errs, fmt.Errorf("modules.sequence: неизвестный модуль %q, доступны: %s", name, strings.Join(nameList, ", ")))
+		}
 	}
 
-	if err := yaml.Unmarshal(data, &wrapper); err != nil {
-		return nil, fmt.Errorf("error unmarshaling modules_config: %v", err)
+	switch c.Mode {
+	case ModeRandom:
+		if c.ModulesCount.Min < 0 || c.ModulesCount.Min > c.ModulesCount.Max {
+			errs = append(errs, fmt.Errorf("modules.modules_count: min (%d) больше max (%d) или отрицательный", c.ModulesCount.Min, c.ModulesCount.Max))
+		}
+		if enabled == 0 && c.ModulesCount.Max > 0 {
+			errs = append(errs, fmt.Errorf("modules.enabled: в режиме %q нужно включить хотя бы один модуль кроме relay", ModeRandom))
+		}
+	case ModeSequence:
+		if len(c.Sequence) == 0 {
+			errs = append(errs, fmt.Errorf("modules.sequence: пустая последовательность в режиме %q", ModeSequence))
+		}
+	case ModeEth:
+	default:
+		errs = append(errs, fmt.Errorf("modules.mode: неизвестный режим %q, доступны: %s, %s, %s", c.Mode, ModeRandom, ModeSequence, ModeEth))
 	}
 
-	return &wrapper.Modules, nil
+	return errs
 }
@@ -1,18 +1,32 @@
 ﻿package configs
 
-import (
-	"fmt"
-	"gopkg.in/yaml.v2"
-	"os"
-)
-
 type NetworkConfig struct {
-	Chains []string `yaml:"chains"`
+	Chains      []string `yaml:"chains"`
+	CheapestGas bool     `yaml:"cheapest_gas"`
 }
 
 type RelayConfig struct {
 	EthBridge EthBridgeConfig `yaml:"eth_bridge"`
 	Networks  NetworkConfig   `yaml:"networks"`
+	Withdraw  WithdrawConfig  `yaml:"relay_withdraw"`
+}
+
+// WithdrawTokens are the Eclipse tokens Relay can withdraw to an L2.
+var WithdrawTokens = []string{"ETH", "USDC"}
+
+type WithdrawConfig struct {
+	Chains []string     `yaml:"chains"`
+	Tokens []string     `yaml:"tokens"`
+	ETH    AmountConfig `yaml:"eth"`
+	USDC   AmountConfig `yaml:"usdc"`
+}
+
+// Amount is the configured withdrawal range of symbol.
+func (c WithdrawConfig) Amount(symbol string) AmountConfig {
+	if symbol == "USDC" {
+		return c.USDC
+	}
+	return c.ETH
 }
 
 type EthBridgeConfig struct {
@@ -22,19 +36,3 @@ type EthBridgeConfig struct {
 	MinPrecision int     `yaml:"min_precision"`
 	MaxPrecision int     `yaml:"max_precision"`
 }
-
-func NewRelayConfig() (*RelayConfig, error) {
-	data, err := os.ReadFile("../data/config.yaml")
-	if err != nil {
-		return nil, fmt.Errorf("error when reading relay_config: %v", err)
-	}
-
-	var config RelayConfig
-
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, fmt.Errorf("error when unmarshal to relay_config: %v", err)
-	}
-
-	return &config, nil
-}
@@ -1,19 +1,19 @@
 ﻿package configs
 
-import "math/rand"
-
 type Chain struct {
 	ChainID int    `json:"chainId"`
 	Name    string `json:"name"`
 	RPC     string `json:"rpc"`
 	ScanURL string `json:"scanUrl"`
+	// USDC is the USDC contract withdrawals are delivered in, empty on Eclipse.
+	USDC string `json:"usdc"`
 }
 
 var (
 	EclipseChain = Chain{
 		ChainID: 9286185,
 		Name:    "Eclipse",
-		RPC:     "https://rpc.eclipse.io",
+		RPC:     "https://mainnetbeta-rpc.eclipse.xyz",
 		ScanURL: "https://eclipsescan.xyz/tx/",
 	}
 
@@ -22,6 +22,7 @@ var (
 		Name:    "Base",
 		RPC:     "https://mainnet.base.org",
 		ScanURL: "https://basescan.org/tx/",
+		USDC:    "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
 	}
 
 	ArbitrumChain = Chain{
@@ -29,6 +30,7 @@ var (
 		Name:    "Arbitrum",
 		RPC:     "https://rpc.ankr.com/arbitrum",
 		ScanURL: "https://arbiscan.io/tx/",
+		USDC:    "0xaf88d065e77c8cC2239327C5EDb3A432268e5831",
 	}
 
 	LineaChain = Chain{
@@ -36,6 +38,7 @@ var (
 		Name:    "Linea",
 		RPC:     "https://linea.drpc.org",
 		ScanURL: "https://lineascan.build/tx/",
+		USDC:    "0x176211869cA2b568f2A7D4EE941E073a821EE1ff",
 	}
 
 	OptimismChain = Chain{
@@ -43,6 +46,7 @@ var (
 		Name:    "Optimism",
 		RPC:     "https://rpc.ankr.com/optimism",
 		ScanURL: "https://optimistic.etherscan.io/tx/",
+		USDC:    "0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85",
 	}
 
 	ScrollChain = Chain{
@@ -50,6 +54,7 @@ var (
 		Name:    "Scroll",
 		RPC:     "https://rpc.ankr.com/scroll",
 		ScanURL: "https://scrollscan.com/tx/",
+		USDC:    "0x06eFdBFf2a14a7c8E15944D1F4A48F9F95F663A4",
 	}
 
 	ZkSyncChain = Chain{
@@ -57,6 +62,7 @@ var (
 		Name:    "ZkSync",
 		RPC:     "https://rpc.ankr.com/zksync_era",
 		ScanURL: "https://explorer.zksync.io/tx/",
+		USDC:    "0x1d17CBcF0D6D143135aE902365D2E5e2A16538D4",
 	}
 )
 
@@ -78,17 +84,3 @@ func GetChainByName(chainName string) *Chain {
 	}
 	return nil
 }
-
-func GetRandomChainFromNames(chainNames []string) Chain {
-	if len(chainNames) == 0 {
-		return Chain{}
-	}
-
-	randomName := chainNames[rand.Intn(len(chainNames))]
-
-	if chain := GetChainByName(randomName); chain != nil {
-		return *chain
-	}
-
-	return Chain{}
-}
@@ -0,0 +1,34 @@
+package configs
+
+import "fmt"
+
+type FeesConfig struct {
+	Percentile        int    `yaml:"percentile"`
+	MinMicroLamports  uint64 `yaml:"min_micro_lamports"`
+	MaxMicroLamports  uint64 `yaml:"max_micro_lamports"`
+	ComputeUnitMargin int    `yaml:"compute_unit_margin"`
+}
+
+func defaultFeesConfig() FeesConfig {
+	return FeesConfig{
+		Percentile:        75,
+		MaxMicroLamports:  200000,
+		ComputeUnitMargin: 15,
+	}
+}
+
+func (c *FeesConfig) validate() []error {
+	var errs []error
+
+	if c.Percentile < 0 || c.Percentile > 100 {
+		errs = append(errs, fmt.Errorf("fees.percentile: должно быть от 0 до 100, указано %d", c.Percentile))
+	}
+	if c.MinMicroLamports > c.MaxMicroLamports {
+		errs = append(errs, fmt.Errorf("fees: min_micro_lamports (%d) больше max_micro_lamports (%d)", c.MinMicroLamports, c.MaxMicroLamports))
+	}
+	if c.ComputeUnitMargin < 0 || c.ComputeUnitMargin > 100 {
+		errs = append(errs, fmt.Errorf("fees.compute_unit_margin: должно быть от 0 до 100, указано %d", c.ComputeUnitMargin))
+	}
+
+	return errs
+}
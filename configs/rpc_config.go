@@ -0,0 +1,48 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+type RPCEndpoint struct {
+	HTTP string `yaml:"http"`
+	WS   string `yaml:"ws"`
+}
+
+type RPCConfig struct {
+	Eclipse             []RPCEndpoint `yaml:"eclipse"`
+	HealthCheckInterval int           `yaml:"health_check_interval"`
+}
+
+func defaultRPCConfig() RPCConfig {
+	return RPCConfig{
+		Eclipse: []RPCEndpoint{
+			{HTTP: EclipseChain.RPC, WS: "wss://mainnetbeta-rpc.eclipse.xyz"},
+		},
+		HealthCheckInterval: 30,
+	}
+}
+
+func (c *RPCConfig) validate() []error {
+	var errs []error
+
+	if len(c.Eclipse) == 0 {
+		errs = append(errs, fmt.Errorf("rpc.eclipse: нужно указать хотя бы один RPC"))
+	}
+
+	for i, endpoint := range c.Eclipse {
+		if !strings.HasPrefix(endpoint.HTTP, "http://") && !strings.HasPrefix(endpoint.HTTP, "https://") {
+			errs = append(errs, fmt.Errorf("rpc.eclipse[%d].http: ожидается http(s) адрес, указано %q", i, endpoint.HTTP))
+		}
+		if endpoint.WS != "" && !strings.HasPrefix(endpoint.WS, "ws://") && !strings.HasPrefix(endpoint.WS, "wss://") {
+			errs = append(errs, fmt.Errorf("rpc.eclipse[%d].ws: ожидается ws(s) адрес, указано %q", i, endpoint.WS))
+		}
+	}
+
+	if c.HealthCheckInterval < 1 {
+		errs = append(errs, fmt.Errorf("rpc.health_check_interval: должно быть не меньше 1 секунды, указано %d", c.HealthCheckInterval))
+	}
+
+	return errs
+}
@@ -0,0 +1,41 @@
+package configs
+
+import "fmt"
+
+// SlippageConfig holds the allowed slippage of every swap module in basis
+// points, 50 = 0.5%.
+type SlippageConfig struct {
+	Orca       int `yaml:"orca"`
+	Invariant  int `yaml:"invariant"`
+	Lifinity   int `yaml:"lifinity"`
+	Solar      int `yaml:"solar"`
+	GasStation int `yaml:"gas_station"`
+}
+
+func defaultSlippageConfig() SlippageConfig {
+	return SlippageConfig{
+		Orca:       50,
+		Invariant:  50,
+		Lifinity:   50,
+		Solar:      50,
+		GasStation: 100,
+	}
+}
+
+func (c *SlippageConfig) validate() []error {
+	var errs []error
+
+	check := func(name string, bps int) {
+		if bps < 1 || bps > 5000 {
+			errs = append(errs, fmt.Errorf("slippage.%s: должно быть от 1 до 5000 bps, указано %d", name, bps))
+		}
+	}
+
+	check("orca", c.Orca)
+	check("invariant", c.Invariant)
+	check("lifinity", c.Lifinity)
+	check("solar", c.Solar)
+	check("gas_station", c.GasStation)
+
+	return errs
+}
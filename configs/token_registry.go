@@ -0,0 +1,192 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TokenProgramSPL  = "spl"
+	TokenProgram2022 = "token-2022"
+)
+
+// requiredTokens are referenced by modules directly, so the registry must
+// always define them.
+var requiredTokens = []string{"ETH", "SOL", "USDC"}
+
+type Token struct {
+	Symbol   string
+	Address  solana.PublicKey
+	Decimals int8
+	Program  solana.PublicKey
+	Stable   bool
+	Aliases  []string
+	Dexes    []string
+}
+
+// TradableOn reports whether the token can be swapped on the dex with the
+// given config key.
+func (t Token) TradableOn(dex string) bool {
+	for _, d := range t.Dexes {
+		if d == dex {
+			return true
+		}
+	}
+	return false
+}
+
+type tokenEntry struct {
+	Symbol   string   `yaml:"symbol"`
+	Mint     string   `yaml:"mint"`
+	Decimals int8     `yaml:"decimals"`
+	Program  string   `yaml:"program"`
+	Stable   bool     `yaml:"stable"`
+	Aliases  []string `yaml:"aliases"`
+	Dexes    []string `yaml:"dexes"`
+}
+
+type tokenFile struct {
+	Tokens []tokenEntry `yaml:"tokens"`
+}
+
+var (
+	tokens        []Token
+	tokensByName  = make(map[string]int)
+	tokensByMint  = make(map[solana.PublicKey]int)
+	tokenPrograms = map[string]solana.PublicKey{
+		TokenProgramSPL:  solana.TokenProgramID,
+		TokenProgram2022: solana.Token2022ProgramID,
+	}
+)
+
+// LoadTokens reads the token registry from a YAML or JSON file. It has to be
+// called before LoadAppConfig and before any module runs.
+func LoadTokens(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading tokens: %v", err)
+	}
+
+	var file tokenFile
+	decoder := yaml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return fmt.Errorf("ошибка разбора реестра токенов %s: %v", path, err)
+	}
+
+	list := make([]Token, 0, len(file.Tokens))
+	byName := make(map[string]int)
+	byMint := make(map[solana.PublicKey]int)
+	var errs []error
+
+	for i, entry := range file.Tokens {
+		mint, err := solana.PublicKeyFromBase58(entry.Mint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tokens[%d] %s: некорректный mint %q: %v", i, entry.Symbol, entry.Mint, err))
+			continue
+		}
+
+		program, ok := tokenPrograms[entry.Program]
+		if !ok {
+			errs = append(errs, fmt.Errorf("tokens[%d] %s: program должен быть %s или %s, указано %q", i, entry.Symbol, TokenProgramSPL, TokenProgram2022, entry.Program))
+			continue
+		}
+
+		if entry.Decimals < 0 || entry.Decimals > 18 {
+			errs = append(errs, fmt.Errorf("tokens[%d] %s: decimals должно быть от 0 до 18, указано %d", i, entry.Symbol, entry.Decimals))
+		}
+
+		if _, exists := byMint[mint]; exists {
+			errs = append(errs, fmt.Errorf("tokens[%d] %s: mint %s уже указан", i, entry.Symbol, mint))
+		}
+		byMint[mint] = len(list)
+
+		for _, name := range append([]string{entry.Symbol}, entry.Aliases...) {
+			key := strings.ToUpper(name)
+			if key == "" {
+				errs = append(errs, fmt.Errorf("tokens[%d]: пустой symbol или alias", i))
+				continue
+			}
+			if _, exists := byName[key]; exists {
+				errs = append(errs, fmt.Errorf("tokens[%d] %s: имя %s уже занято", i, entry.Symbol, name))
+			}
+			byName[key] = len(list)
+		}
+
+		list = append(list, Token{
+			Symbol:   entry.Symbol,
+			Address:  mint,
+			Decimals: entry.Decimals,
+			Program:  program,
+			Stable:   entry.Stable,
+			Aliases:  entry.Aliases,
+			Dexes:    entry.Dexes,
+		})
+	}
+
+	for _, symbol := range requiredTokens {
+		if _, exists := byName[symbol]; !exists {
+			errs = append(errs, fmt.Errorf("в реестре токенов нет обязательного токена %s", symbol))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("некорректный реестр токенов %s:\n%w", path, errors.Join(errs...))
+	}
+
+	tokens, tokensByName, tokensByMint = list, byName, byMint
+
+	return nil
+}
+
+// LookupToken finds a token by symbol or alias, ignoring case.
+func LookupToken(name string) (Token, bool) {
+	i, exists := tokensByName[strings.ToUpper(name)]
+	if !exists {
+		return Token{}, false
+	}
+	return tokens[i], true
+}
+
+// MustToken is LookupToken for the tokens LoadTokens guarantees to exist.
+func MustToken(name string) Token {
+	t, exists := LookupToken(name)
+	if !exists {
+		panic(fmt.Sprintf("token %s is not in the registry", name))
+	}
+	return t
+}
+
+func TokenByMint(mint solana.PublicKey) (Token, bool) {
+	i, exists := tokensByMint[mint]
+	if !exists {
+		return Token{}, false
+	}
+	return tokens[i], true
+}
+
+func tokenSymbols() []string {
+	symbols := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		symbols = append(symbols, t.Symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func tradableTokens(symbols []string, dex string) []Token {
+	var result []Token
+	for _, symbol := range symbols {
+		if t, exists := LookupToken(symbol); exists && t.TradableOn(dex) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
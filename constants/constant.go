@@ -2,8 +2,6 @@
 
 import "github.com/ethereum/go-ethereum/common"
 
-var ConfigPath = "../data/config.yaml"
-
 var ZeroAddress = common.Address{}
 var ZeroHash = common.Hash{}
 
@@ -4,26 +4,11 @@ import (
 	"eclipse/configs"
 	"eclipse/internal/token"
 	"fmt"
-	"github.com/gagliardetto/solana-go"
 	"math"
 	"math/rand"
 	"time"
 )
 
-type SwapConfig struct {
-	ProgramID     solana.PublicKey
-	PoolAddress   solana.PublicKey
-	StateAddress  solana.PublicKey
-	VaultA        solana.PublicKey
-	VaultB        solana.PublicKey
-	PoolAuthority solana.PublicKey
-	FeeAccount    solana.PublicKey
-	FeeState      solana.PublicKey
-	OracleAddress solana.PublicKey
-	SwapData      []byte
-	NeedsWSol     bool
-}
-
 func GetRandomPrecision(minPrecision, maxPrecision int) int {
 	return minPrecision + rand.Intn(maxPrecision-minPrecision+1)
 }
@@ -55,3 +40,32 @@ func GetRandomTokenPair(tokens []configs.Token) (configs.Token, configs.Token, s
 
 	return firstToken, secondToken, pairType, nil
 }
+
+// GetRandomPoolPair picks a random ordered pair of tokens for which findPool
+// succeeds, trying every pair before giving up.
+func GetRandomPoolPair(tokens []configs.Token, findPool func(first, second configs.Token) error) (configs.Token, configs.Token, string, error) {
+	if len(tokens) < 2 {
+		return configs.Token{}, configs.Token{}, "", fmt.Errorf("insufficient tokens: need at least 2, got %d", len(tokens))
+	}
+
+	var pairs [][2]configs.Token
+	for i := range tokens {
+		for j := range tokens {
+			if i != j {
+				pairs = append(pairs, [2]configs.Token{tokens[i], tokens[j]})
+			}
+		}
+	}
+	rand.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+
+	var lastErr error
+	for _, pair := range pairs {
+		if err := findPool(pair[0], pair[1]); err != nil {
+			lastErr = err
+			continue
+		}
+		return pair[0], pair[1], token.GetPairType(pair[0]), nil
+	}
+
+	return configs.Token{}, configs.Token{}, "", fmt.Errorf("ни для одной пары токенов нет пула: %v", lastErr)
+}
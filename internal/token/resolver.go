@@ -0,0 +1,123 @@
+package token
+
+import (
+	"context"
+	"eclipse/configs"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// The owning program of a mint never changes, so lookups are cached for the
+// whole run and shared by all accounts.
+var (
+	mintPrograms   = make(map[solana.PublicKey]solana.PublicKey)
+	mintProgramsMu sync.RWMutex
+)
+
+// MintProgram returns the token program that owns mint, SPL Token or
+// Token-2022, as read from chain. For mints in the token registry the
+// declared program is only checked against the owner.
+func MintProgram(ctx context.Context, client *rpc.Client, mint solana.PublicKey) (solana.PublicKey, error) {
+	mintProgramsMu.RLock()
+	program, cached := mintPrograms[mint]
+	mintProgramsMu.RUnlock()
+	if cached {
+		return program, nil
+	}
+
+	info, err := client.GetAccountInfoWithOpts(ctx, mint, &rpc.GetAccountInfoOpts{
+		Commitment: rpc.CommitmentFinalized,
+		DataSlice:  &rpc.DataSlice{Offset: new(uint64), Length: new(uint64)},
+	})
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("не удалось загрузить mint %s: %v", mint, err)
+	}
+
+	program = info.Value.Owner
+	if !program.Equals(solana.TokenProgramID) && !program.Equals(solana.Token2022ProgramID) {
+		return solana.PublicKey{}, fmt.Errorf("%s не является mint токена, владелец %s", mint, program)
+	}
+	if t, exists := configs.TokenByMint(mint); exists && !t.Program.Equals(program) {
+		return solana.PublicKey{}, fmt.Errorf("в реестре токенов у %s указана программа %s, а mint принадлежит %s, исправьте data/tokens.yaml", t.Symbol, t.Program, program)
+	}
+
+	mintProgramsMu.Lock()
+	mintPrograms[mint] = program
+	mintProgramsMu.Unlock()
+
+	return program, nil
+}
+
+// ResolveATA derives the associated token account of owner for mint under
+// the program that owns the mint.
+func ResolveATA(ctx context.Context, client *rpc.Client, owner, mint solana.PublicKey) (solana.PublicKey, solana.PublicKey, error) {
+	program, err := MintProgram(ctx, client, mint)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, err
+	}
+
+	ata, _, err := solana.FindProgramAddress([][]byte{
+		owner.Bytes(),
+		program.Bytes(),
+		mint.Bytes(),
+	}, ATA_PROGRAM_ID)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, err
+	}
+
+	return ata, program, nil
+}
+
+// CreateATAIdempotent returns the ATA of owner for mint together with an
+// instruction that creates it, or does nothing when it already exists.
+func CreateATAIdempotent(ctx context.Context, client *rpc.Client, payer, owner, mint solana.PublicKey) (solana.PublicKey, solana.Instruction, error) {
+	ata, program, err := ResolveATA(ctx, client, owner, mint)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+
+	return ata, solana.NewInstruction(
+		ATA_PROGRAM_ID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(payer, true, true),
+			solana.NewAccountMeta(ata, true, false),
+			solana.NewAccountMeta(owner, false, false),
+			solana.NewAccountMeta(mint, false, false),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+			solana.NewAccountMeta(program, false, false),
+		},
+		[]byte{1},
+	), nil
+}
+
+// GetATABalance returns the raw token balance of owner for mint. A token
+// account that does not exist yet holds nothing, so it reads as zero.
+func GetATABalance(ctx context.Context, client *rpc.Client, owner, mint solana.PublicKey, commitment rpc.CommitmentType) (uint64, error) {
+	ata, _, err := ResolveATA(ctx, client, owner, mint)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := client.GetAccountInfoWithOpts(ctx, ata, &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+	})
+	if errors.Is(err, rpc.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения аккаунта %s: %v", ata, err)
+	}
+
+	data := info.Value.Data.GetBinary()
+	if len(data) < 72 {
+		return 0, fmt.Errorf("аккаунт %s не является токен аккаунтом", ata)
+	}
+
+	return binary.LittleEndian.Uint64(data[64:72]), nil
+}
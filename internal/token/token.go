@@ -15,20 +15,10 @@ type SwapInstructions struct {
 	TokenDecimals int8
 }
 
-var TOKEN_2022_PROGRAM_ID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
 var ATA_PROGRAM_ID = solana.MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
 
-func FindAssociatedTokenAddress2022(wallet, mint solana.PublicKey) (solana.PublicKey, uint8, error) {
-	seeds := [][]byte{
-		wallet.Bytes(),
-		TOKEN_2022_PROGRAM_ID.Bytes(),
-		mint.Bytes(),
-	}
-	return solana.FindProgramAddress(seeds, ATA_PROGRAM_ID)
-}
-
 func GetPairType(first configs.Token) string {
-	if first.Symbol == "USDC" || first.Symbol == "USDT" {
+	if first.Stable {
 		return "stable"
 	}
 
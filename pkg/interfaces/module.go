@@ -5,66 +5,71 @@ import (
 	"database/sql"
 	"eclipse/configs"
 	"eclipse/model"
+	"eclipse/pkg/services/blockchain/fees"
 	"eclipse/pkg/services/telegram"
 	"github.com/gagliardetto/solana-go/rpc"
 	"net/http"
 )
 
-type ModuleType int
+type ProxyManagerInterface interface {
+	GetProxyURL(accountIndex int) string
+}
 
-const (
-	OrcaType ModuleType = iota
-	UnderdogType
-	DefaultType
-)
+type ExecutionContext struct {
+	HttpClient     http.Client
+	RpcClient      *rpc.Client
+	Config         configs.AppConfig
+	EvmAccount     *model.EvmAccount
+	EclipseAccount *model.EclipseAccount
+	AccountIndex   int
+	ProxyManager   ProxyManagerInterface
+	Notifier       *telegram.Notifier
+	DB             *sql.DB
+	Words          []string
+}
 
-type ModuleInfo struct {
-	Module interface{}
-	Type   ModuleType
+func (e ExecutionContext) MaxAttempts() int {
+	return e.Config.Delay.BetweenRetries.Attempts
 }
 
-type ProxyManagerInterface interface {
-	GetProxyURL(accountIndex int) string
+func (e ExecutionContext) FeePolicy() *fees.Policy {
+	if e.Config.Fees == nil {
+		return nil
+	}
+	return fees.NewPolicy(e.RpcClient, *e.Config.Fees)
+}
+
+type Module interface {
+	Execute(ctx context.Context, exec ExecutionContext) (bool, error)
 }
 
-type OrcaModule interface {
-	Execute(
-		ctx context.Context,
-		rpcClient *rpc.Client,
-		cfg configs.AppConfig,
-		acc *model.EclipseAccount,
-		proxyManager ProxyManagerInterface,
-		notifier *telegram.Notifier,
-		db *sql.DB,
-		accountIndex int,
-		maxAttempts int,
-	) (bool, error)
+// Quote is the expected ETH output of a swap in lamports, net of pool fees,
+// and what the swap transaction costs on top: signature and priority fees
+// plus rent that is not refunded.
+type Quote struct {
+	AmountOut uint64
+	Fee       uint64
+	// Simulated is set when AmountOut comes from simulating the swap on an
+	// RPC node rather than from the route's own pool math or API.
+	Simulated bool
 }
 
-type UnderdogModule interface {
-	Execute(
-		ctx context.Context,
-		httpClient http.Client,
-		rpcClient *rpc.Client,
-		cfg configs.AppConfig,
-		acc *model.EclipseAccount,
-		notifier *telegram.Notifier,
-		db *sql.DB,
-		words []string,
-		minEthHold float64,
-		maxAttempts int,
-	) (bool, error)
+func (q Quote) Net() uint64 {
+	if q.Fee >= q.AmountOut {
+		return 0
+	}
+	return q.AmountOut - q.Fee
 }
 
-type DefaultModule interface {
-	Execute(
-		ctx context.Context,
-		httpClient http.Client,
-		rpcClient *rpc.Client,
-		cfg configs.AppConfig,
-		acc *model.EclipseAccount,
-		notifier *telegram.Notifier,
-		db *sql.DB,
-		maxAttempts int,
-	) (bool, error)
+// Quoter is implemented by swap modules that can price the eth mode
+// USDC -> ETH swap of amount without sending anything.
+type Quoter interface {
+	QuoteUsdcToEth(ctx context.Context, exec ExecutionContext, amount uint64) (Quote, error)
+}
+
+type ModuleInfo struct {
+	Name      string
+	ConfigKey string
+	IsSwap    bool
+	Module    Module
 }
@@ -0,0 +1,66 @@
+package interfaces
+
+import (
+	"eclipse/configs"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registry      = make(map[string]ModuleInfo)
+	registryMutex sync.RWMutex
+)
+
+func Register(info ModuleInfo) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, exists := registry[info.Name]; exists {
+		panic(fmt.Sprintf("module %s already registered", info.Name))
+	}
+
+	registry[info.Name] = info
+}
+
+func GetModule(name string) (ModuleInfo, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	info, exists := registry[name]
+	return info, exists
+}
+
+func RegisteredModules() []ModuleInfo {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	modules := make([]ModuleInfo, 0, len(registry))
+	for _, info := range registry {
+		modules = append(modules, info)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
+
+	return modules
+}
+
+func ModuleSpecs() []configs.ModuleSpec {
+	var specs []configs.ModuleSpec
+	for _, info := range RegisteredModules() {
+		specs = append(specs, configs.ModuleSpec{Name: info.Name, ConfigKey: info.ConfigKey})
+	}
+	return specs
+}
+
+func SwapModules() []ModuleInfo {
+	var modules []ModuleInfo
+	for _, info := range RegisteredModules() {
+		if info.IsSwap {
+			modules = append(modules, info)
+		}
+	}
+	return modules
+}
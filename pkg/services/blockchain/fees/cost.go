@@ -0,0 +1,131 @@
+package fees
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/internal/token"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	budgetSetLimit = 2
+	budgetSetPrice = 3
+
+	// defaultUnitsPerInstruction is the limit the runtime grants every
+	// instruction of a transaction that sets none.
+	defaultUnitsPerInstruction = 200_000
+
+	tokenAccountSize = 165
+	closeAccountIx   = 9
+)
+
+// Cost is what sending instructions costs in lamports: the base fee of every
+// signature plus the priority fee their compute budget instructions set,
+// the unit price times the unit limit.
+func Cost(instructions []solana.Instruction, signatures int) uint64 {
+	var price, limit uint64
+	hasLimit := false
+	other := 0
+
+	for _, inst := range instructions {
+		data, _ := inst.Data()
+		switch budgetKind(inst) {
+		case budgetSetLimit:
+			if len(data) >= 5 {
+				limit = uint64(binary.LittleEndian.Uint32(data[1:5]))
+				hasLimit = true
+			}
+		case budgetSetPrice:
+			if len(data) >= 9 {
+				price = binary.LittleEndian.Uint64(data[1:9])
+			}
+		case -1:
+			other++
+		}
+	}
+
+	if !hasLimit {
+		limit = min(uint64(other)*defaultUnitsPerInstruction, maxComputeUnits)
+	}
+
+	// price is in micro-lamports per unit, rounded up like the runtime does
+	return uint64(signatures)*SignatureFee + (price*limit+999_999)/1_000_000
+}
+
+// Reserve is the most a transaction of units compute units costs under cfg:
+// one signature plus the priority fee at the price cap.
+func Reserve(cfg configs.FeesConfig, units uint32) uint64 {
+	return SignatureFee + (cfg.MaxMicroLamports*uint64(units)+999_999)/1_000_000
+}
+
+// UnrefundedRent is the rent locked in the associated token accounts
+// instructions create and do not close again. Accounts that already exist
+// cost nothing.
+func UnrefundedRent(ctx context.Context, client *rpc.Client, instructions []solana.Instruction) (uint64, error) {
+	closed := make(map[solana.PublicKey]bool)
+	var created []solana.PublicKey
+
+	for _, inst := range instructions {
+		accounts := inst.Accounts()
+		program := inst.ProgramID()
+
+		switch {
+		case program.Equals(token.ATA_PROGRAM_ID) && len(accounts) > 1:
+			created = append(created, accounts[1].PublicKey)
+		case program.Equals(solana.TokenProgramID) || program.Equals(solana.Token2022ProgramID):
+			data, _ := inst.Data()
+			if len(data) == 1 && data[0] == closeAccountIx && len(accounts) > 0 {
+				closed[accounts[0].PublicKey] = true
+			}
+		}
+	}
+
+	var kept []solana.PublicKey
+	for _, account := range created {
+		if !closed[account] {
+			kept = append(kept, account)
+		}
+	}
+	if len(kept) == 0 {
+		return 0, nil
+	}
+
+	res, err := client.GetMultipleAccountsWithOpts(ctx, kept, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось проверить токен аккаунты: %v", err)
+	}
+
+	missing := uint64(0)
+	for _, account := range res.Value {
+		if account == nil {
+			missing++
+		}
+	}
+	if missing == 0 {
+		return 0, nil
+	}
+
+	rent, err := client.GetMinimumBalanceForRentExemption(ctx, tokenAccountSize, rpc.CommitmentConfirmed)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить ренту токен аккаунта: %v", err)
+	}
+
+	return missing * rent, nil
+}
+
+// RouteFee is what a route built from instructions costs on top of its
+// output: network fees plus rent that is not refunded.
+func RouteFee(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, signatures int) (uint64, error) {
+	rent, err := UnrefundedRent(ctx, client, instructions)
+	if err != nil {
+		return 0, err
+	}
+
+	return Cost(instructions, signatures) + rent, nil
+}
@@ -0,0 +1,42 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+func TestCost(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	transfer := system.NewTransferInstruction(1, payer, payer).Build()
+	limit := func(units uint32) solana.Instruction {
+		return computebudget.NewSetComputeUnitLimitInstruction(units).Build()
+	}
+	price := func(microLamports uint64) solana.Instruction {
+		return computebudget.NewSetComputeUnitPriceInstruction(microLamports).Build()
+	}
+
+	tests := []struct {
+		name         string
+		instructions []solana.Instruction
+		signatures   int
+		want         uint64
+	}{
+		{name: "no priority fee", instructions: []solana.Instruction{transfer}, signatures: 1, want: 5000},
+		{name: "two signatures", instructions: []solana.Instruction{transfer}, signatures: 2, want: 10000},
+		{name: "explicit limit", instructions: []solana.Instruction{limit(100_000), price(200_000), transfer}, signatures: 1, want: 25000},
+		{name: "default limit", instructions: []solana.Instruction{price(10_000), transfer, transfer}, signatures: 1, want: 9000},
+		{name: "default limit capped", instructions: []solana.Instruction{price(1_000_000), transfer, transfer, transfer, transfer, transfer, transfer, transfer, transfer}, signatures: 1, want: 1_405_000},
+		{name: "rounded up", instructions: []solana.Instruction{limit(1), price(1), transfer}, signatures: 1, want: 5001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cost(tt.instructions, tt.signatures); got != tt.want {
+				t.Errorf("Cost() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
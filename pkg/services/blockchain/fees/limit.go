@@ -0,0 +1,59 @@
+package fees
+
+import (
+	"context"
+	"eclipse/pkg/services/blockchain/sender"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const maxComputeUnits = 1_400_000
+
+// Limit simulates instructions under the maximum unit limit and returns the
+// consumed units plus the configured margin.
+func (p *Policy) Limit(ctx context.Context, instructions []solana.Instruction, payer solana.PublicKey, tables sender.LookupTables) (uint32, error) {
+	maxIx := computebudget.NewSetComputeUnitLimitInstruction(maxComputeUnits).Build()
+
+	tx, err := sender.NewTransaction(append([]solana.Instruction{maxIx}, instructions...), solana.Hash{}, payer, tables)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := p.client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		Commitment:             rpc.CommitmentProcessed,
+		ReplaceRecentBlockhash: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if res.Value.Err != nil {
+		return 0, fmt.Errorf("симуляция завершилась ошибкой: %v", res.Value.Err)
+	}
+	if res.Value.UnitsConsumed == nil || *res.Value.UnitsConsumed == 0 {
+		return 0, fmt.Errorf("RPC не вернул потраченные CU")
+	}
+
+	limit := *res.Value.UnitsConsumed * uint64(100+p.cfg.ComputeUnitMargin) / 100
+	if limit > maxComputeUnits {
+		limit = maxComputeUnits
+	}
+
+	return uint32(limit), nil
+}
+
+func budgetKind(inst solana.Instruction) int {
+	if !inst.ProgramID().Equals(computebudget.ProgramID) {
+		return -1
+	}
+
+	data, err := inst.Data()
+	if err != nil || len(data) == 0 {
+		return -1
+	}
+
+	return int(data[0])
+}
@@ -0,0 +1,117 @@
+package fees
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/internal/logger"
+	"eclipse/pkg/services/blockchain/sender"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SignatureFee is the base fee in lamports paid for every signature.
+const SignatureFee = 5000
+
+// Policy prices compute units from the fees recently paid for the accounts
+// a transaction writes to, clamped to the configured range, and limits them
+// to what a simulation of the transaction consumed.
+type Policy struct {
+	client *rpc.Client
+	cfg    configs.FeesConfig
+}
+
+func NewPolicy(client *rpc.Client, cfg configs.FeesConfig) *Policy {
+	return &Policy{client: client, cfg: cfg}
+}
+
+func (p *Policy) Price(ctx context.Context, accounts []solana.PublicKey) uint64 {
+	result, err := p.client.GetRecentPrioritizationFees(ctx, accounts)
+	if err != nil {
+		logger.Warning("Не удалось получить priority fee, использую минимальный %d: %v", p.cfg.MinMicroLamports, err)
+		return p.cfg.MinMicroLamports
+	}
+
+	recent := make([]uint64, 0, len(result))
+	for _, r := range result {
+		recent = append(recent, r.PrioritizationFee)
+	}
+
+	return p.clamp(percentile(recent, p.cfg.Percentile))
+}
+
+// Apply strips any ComputeBudget instructions from instructions and
+// prepends a unit limit sized from simulation and a price chosen by the
+// policy. tables are the lookup tables the transaction will be compiled
+// with, so the simulation matches what is sent. A transaction whose
+// simulation fails is not sent with a guessed limit.
+func (p *Policy) Apply(ctx context.Context, instructions []solana.Instruction, payer solana.PublicKey, tables sender.LookupTables) ([]solana.Instruction, error) {
+	if p == nil {
+		return instructions, nil
+	}
+
+	rest := make([]solana.Instruction, 0, len(instructions))
+	for _, inst := range instructions {
+		if budgetKind(inst) < 0 {
+			rest = append(rest, inst)
+		}
+	}
+
+	price := p.Price(ctx, writableAccounts(rest))
+	priceIx := computebudget.NewSetComputeUnitPriceInstruction(price).Build()
+
+	limit, err := p.Limit(ctx, append([]solana.Instruction{priceIx}, rest...), payer, tables)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось рассчитать лимит CU: %v", err)
+	}
+
+	limitIx := computebudget.NewSetComputeUnitLimitInstruction(limit).Build()
+	logger.Info("Priority fee: %d micro-lamports за CU, лимит %d CU", price, limit)
+
+	return append([]solana.Instruction{limitIx, priceIx}, rest...), nil
+}
+
+func (p *Policy) clamp(price uint64) uint64 {
+	if price < p.cfg.MinMicroLamports {
+		return p.cfg.MinMicroLamports
+	}
+	if price > p.cfg.MaxMicroLamports {
+		return p.cfg.MaxMicroLamports
+	}
+	return price
+}
+
+func percentile(values []uint64, pct int) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	idx := (len(values) - 1) * pct / 100
+	return values[idx]
+}
+
+func writableAccounts(instructions []solana.Instruction) []solana.PublicKey {
+	seen := make(map[solana.PublicKey]bool)
+	var accounts []solana.PublicKey
+
+	for _, inst := range instructions {
+		for _, meta := range inst.Accounts() {
+			if meta.IsWritable && !seen[meta.PublicKey] {
+				seen[meta.PublicKey] = true
+				accounts = append(accounts, meta.PublicKey)
+			}
+		}
+	}
+
+	// the rpc accepts at most 128 accounts
+	if len(accounts) > 128 {
+		accounts = accounts[:128]
+	}
+
+	return accounts
+}
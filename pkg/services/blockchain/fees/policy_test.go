@@ -0,0 +1,94 @@
+package fees
+
+import (
+	"context"
+	"eclipse/configs"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// feeServer answers getRecentPrioritizationFees with fees, or with an error
+// when fees is nil, and records the accounts it was asked about.
+func feeServer(t *testing.T, fees []uint64, accounts *[]string) *rpc.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []interface{}   `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getRecentPrioritizationFees" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		if accounts != nil && len(req.Params) > 0 {
+			for _, account := range req.Params[0].([]interface{}) {
+				*accounts = append(*accounts, account.(string))
+			}
+		}
+
+		if fees == nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"unavailable"}}`, req.ID)
+			return
+		}
+
+		result := make([]map[string]uint64, len(fees))
+		for i, fee := range fees {
+			result[i] = map[string]uint64{"slot": uint64(i + 1), "prioritizationFee": fee}
+		}
+		body, _ := json.Marshal(result)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return rpc.New(server.URL)
+}
+
+func TestPolicyPrice(t *testing.T) {
+	cfg := configs.FeesConfig{Percentile: 75, MinMicroLamports: 100, MaxMicroLamports: 50_000}
+
+	tests := []struct {
+		name string
+		cfg  configs.FeesConfig
+		fees []uint64
+		want uint64
+	}{
+		{name: "percentile", cfg: cfg, fees: []uint64{4000, 1000, 3000, 2000, 5000}, want: 4000},
+		{name: "median", cfg: configs.FeesConfig{Percentile: 50, MaxMicroLamports: 50_000}, fees: []uint64{300, 100, 200}, want: 200},
+		{name: "highest", cfg: configs.FeesConfig{Percentile: 100, MaxMicroLamports: 50_000}, fees: []uint64{300, 100, 200}, want: 300},
+		{name: "clamped to max", cfg: cfg, fees: []uint64{90_000, 80_000, 70_000}, want: 50_000},
+		{name: "clamped to min", cfg: cfg, fees: []uint64{0, 0, 10}, want: 100},
+		{name: "no recent fees", cfg: cfg, fees: []uint64{}, want: 100},
+		{name: "rpc error", cfg: cfg, fees: nil, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPolicy(feeServer(t, tt.fees, nil), tt.cfg)
+
+			if got := policy.Price(context.Background(), nil); got != tt.want {
+				t.Errorf("Price() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyPriceAccounts(t *testing.T) {
+	var accounts []string
+	policy := NewPolicy(feeServer(t, []uint64{1}, &accounts), configs.FeesConfig{MaxMicroLamports: 1})
+
+	pool := solana.NewWallet().PublicKey()
+	policy.Price(context.Background(), []solana.PublicKey{pool})
+
+	if len(accounts) != 1 || accounts[0] != pool.String() {
+		t.Errorf("asked about %v, want [%s]", accounts, pool)
+	}
+}
@@ -2,43 +2,47 @@ package gas_station
 
 import (
 	"context"
-	"database/sql"
 	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
 	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/randomizer"
-	"eclipse/pkg/services/telegram"
 	"eclipse/utils/balance"
 	"fmt"
-	"log"
-	"net/http"
 	"strconv"
 	"time"
-
-	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 )
 
-var usdc = "AKEWE7Bgh87GPp171b4cJPSSZfmZwQ3KaqYqXoKLNAEE"
-
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	httpClient http.Client,
-	rpcClient *rpc.Client,
-	cfg configs.AppConfig,
-	acc *model.EclipseAccount,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Gas Station",
+		ConfigKey: "gas_station",
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Gas Station")
 
+	cfg := exec.Config
+	acc := exec.EclipseAccount
+	httpClient := exec.HttpClient
+	rpcClient := exec.RpcClient
+	notifier := exec.Notifier
+	db := exec.DB
+	maxAttempts := exec.MaxAttempts()
+	tracker := sender.NewTracker(rpcClient)
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		value, valueStr := randomizer.GetRandomValueWithPrecision(
 			cfg.Invariant.Stable.MinValue,
 			cfg.Invariant.Stable.MaxValue,
@@ -56,7 +60,7 @@ func (m *Module) Execute(
 
 		params := token.SwapInstructions{
 			Payer:         acc.PrivateKey,
-			FirstToken:    solana.MustPublicKeyFromBase58(usdc),
+			FirstToken:    configs.MustToken("USDC").Address,
 			Amount:        amountDecimals,
 			TokenSymbol:   "USDC",
 			TokenDecimals: 6,
@@ -75,20 +79,25 @@ func (m *Module) Execute(
 
 		request := SwapRequest{
 			User:              acc.PublicKey.String(),
-			SourceMint:        usdc,
+			SourceMint:        configs.MustToken("USDC").Address.String(),
 			Amount:            amount,
-			SlippingTolerance: 1,
+			SlippingTolerance: slippagePercent(cfg.Slippage.GasStation),
 		}
 
 		response, err := GetTxData(httpClient, request)
 		if err != nil {
-			log.Fatal(err)
+			return false, fmt.Errorf("error getting swap transaction: %v", err)
 		}
 
-		sig, err := SendTransaction(ctx, rpcClient, acc.PrivateKey, response.Transaction)
+		sig, err := SendTransaction(ctx, rpcClient, acc.PrivateKey, response.Transaction, tracker)
 		if err != nil {
 			logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-			time.Sleep(3 * time.Second)
+			if err := tracker.Unresolved(); err != nil {
+				return false, err
+			}
+			if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+				return false, err
+			}
 			continue
 		} else {
 			if db != nil && cfg.Database.Enabled {
@@ -117,3 +126,9 @@ func (m *Module) Execute(
 	notifier.AddErrorMessage(acc.PublicKey.String(), "Gas Station")
 	return false, fmt.Errorf("could not execute swap after %d attempts", maxAttempts)
 }
+
+// slippagePercent converts bps to the whole percents the Gas Station API
+// accepts, rounding up so a small slippage never becomes zero.
+func slippagePercent(bps int) int {
+	return (bps + 99) / 100
+}
@@ -2,25 +2,32 @@
 
 import (
 	"context"
-	"eclipse/constants"
+	"eclipse/configs"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/pkg/services/blockchain/lifinity"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
 	"encoding/binary"
-	"encoding/hex"
+	"errors"
 	"fmt"
-	"time"
+	"math/big"
 
 	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
 var (
-	TOKEN_PROGRAM_ID     = solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
 	SYSTEM_PROGRAM_ID    = solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
 	INVARIANT_PROGRAM_ID = solana.MustPublicKeyFromBase58("iNvTyprs4TX8m6UeUEkeqDFjAL9zRCRWcexK9Sd4WEU")
+	INVARIANT_STATE      = solana.MustPublicKeyFromBase58("J1GyvGaY3XPNGFQkMNx7C5aT48duYRrAJE75Evk3neas")
+	INVARIANT_AUTHORITY  = solana.MustPublicKeyFromBase58("D4P9HJYPczLFHvxBgpLKooy7eWczci8pr4x9Zu7iYCVN")
 )
 
+// tickAccountSize is what every tick adds to the transaction, its address
+// and its index in the swap instruction.
+const tickAccountSize = solana.PublicKeyLength + 1
+
 func createCreateAccountInstruction(params token.SwapInstructions, newAccount solana.PublicKey) solana.Instruction {
 	data := make([]byte, 52)
 
@@ -28,8 +35,7 @@ func createCreateAccountInstruction(params token.SwapInstructions, newAccount so
 	binary.LittleEndian.PutUint64(data[4:12], 19924)
 	binary.LittleEndian.PutUint64(data[12:20], 165)
 
-	ownerPubkey := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
-	copy(data[20:52], ownerPubkey.Bytes())
+	copy(data[20:52], solana.TokenProgramID.Bytes())
 
 	return solana.NewInstruction(
 		SYSTEM_PROGRAM_ID,
@@ -60,10 +66,10 @@ func createInitializeAccountInstruction(params token.SwapInstructions, newAccoun
 	RENT_PROGRAM_ID := solana.MustPublicKeyFromBase58("SysvarRent111111111111111111111111111111111")
 
 	return solana.NewInstruction(
-		TOKEN_PROGRAM_ID,
+		solana.TokenProgramID,
 		solana.AccountMetaSlice{
 			solana.NewAccountMeta(newAccount, true, true),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58(WRAPPED_ETH_ADDRESS), false, false),
+			solana.NewAccountMeta(configs.MustToken("ETH").Address, false, false),
 			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
 			solana.NewAccountMeta(RENT_PROGRAM_ID, false, false),
 		},
@@ -71,90 +77,41 @@ func createInitializeAccountInstruction(params token.SwapInstructions, newAccoun
 	)
 }
 
-func createSwapInstruction(params token.SwapInstructions, tempAccount solana.PublicKey) solana.Instruction {
-	mint := "AKEWE7Bgh87GPp171b4cJPSSZfmZwQ3KaqYqXoKLNAEE"
-
-	mintKey := solana.MustPublicKeyFromBase58(mint)
-
-	destinationATA, _, err := token.FindAssociatedTokenAddress2022(params.Payer.PublicKey(), mintKey)
-	if err != nil {
-		logger.Error("Error finding associated token address: %v", err)
-	}
-
+func createSwapInstruction(params token.SwapInstructions, pool *Pool, xToY bool, accountX, accountY, programX, programY solana.PublicKey, ticks []solana.PublicKey, sqrtPriceLimit *big.Int) solana.Instruction {
 	data := make([]byte, 34)
 	discriminator := []byte{
 		0xf8, 0xc6, 0x9e, 0x91, 0xe1, 0x75, 0x87, 0xc8,
 	}
 	copy(data[0:8], discriminator)
 
-	data[8] = 1
+	if xToY {
+		data[8] = 1
+	}
 
 	binary.LittleEndian.PutUint64(data[9:17], params.Amount)
 
 	data[17] = 1
 
-	sqrtPriceLimitHex := "0"
-	sqrtPriceLimitBytes, _ := hex.DecodeString(sqrtPriceLimitHex)
-	copy(data[18:34], sqrtPriceLimitBytes)
-
-	var accountMetas solana.AccountMetaSlice
+	writeUint128(data[18:34], sqrtPriceLimit)
+
+	accountMetas := solana.AccountMetaSlice{
+		solana.NewAccountMeta(INVARIANT_STATE, false, false),
+		solana.NewAccountMeta(pool.Address, true, false),
+		solana.NewAccountMeta(pool.Tickmap, true, false),
+		solana.NewAccountMeta(pool.TokenX, false, false),
+		solana.NewAccountMeta(pool.TokenY, false, false),
+		solana.NewAccountMeta(accountX, true, false),
+		solana.NewAccountMeta(accountY, true, false),
+		solana.NewAccountMeta(pool.ReserveX, true, false),
+		solana.NewAccountMeta(pool.ReserveY, true, false),
+		solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
+		solana.NewAccountMeta(INVARIANT_AUTHORITY, false, false),
+		solana.NewAccountMeta(programX, false, false),
+		solana.NewAccountMeta(programY, false, false),
+	}
 
-	if params.IsETH {
-		accountMetas = solana.AccountMetaSlice{
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("J1GyvGaY3XPNGFQkMNx7C5aT48duYRrAJE75Evk3neas"), false, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("FdEcxaJ9cDW5Y3AZ79eDtzdvK7uaxSEfn5vPb47ew5yg"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("4qw2sfWzrkhVWEHfXJsG7g7h6hyGuwsaL3Cit8u5XZsj"), true, false),
-			solana.NewAccountMeta(params.SecondToken, false, false),
-			solana.NewAccountMeta(params.FirstToken, false, false),
-			solana.NewAccountMeta(destinationATA, true, false),
-			solana.NewAccountMeta(tempAccount, true, true),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("EUC57x63cdmsCRFDPtZcd5Ko8T4qmA8RRcvun96N96Jv"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("6pgHDYgEzzYT8e1em6r5kchp3PJ8E5gfR6chhSgT9o1P"), true, false),
-			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("D4P9HJYPczLFHvxBgpLKooy7eWczci8pr4x9Zu7iYCVN"), false, false),
-			solana.NewAccountMeta(lifinity.TOKEN_2022_PROGRAM_ID, false, false),
-			solana.NewAccountMeta(TOKEN_PROGRAM_ID, false, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("EwG2ZsoZvXW5Mp7Gc2VXrSkEehTH9B7EntCmR8fcnQtQ"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("95vhcMhq1nS4pyYwV5dd2GiLx7Vux1VUm74o68QGbTuw"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("B3QN7GUiZQbMb5sxQkEYd1fjuxSRHQQ8aUY8HWjLSaaR"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("zscN6fjzXzj1fmHCU6ey5w51SxXyZ7qWdb4RiABNoDM"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("FRhLPgegA1sbr4zfcD7xSbRRVsGu7QstnUCeCV3GCJDq"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("FBJwWWR2eDfJTQpR9FYqYjQcsx8fCXdpJ5gXQSpQvy8z"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("4zkqTKeoCVBaHn8NZXJmUSic7LWQnooLXBuRmbcSgbrC"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("7i429eLMEdLxYaTJRjEffUr1UdRFr5zrCX6vNeZFKtcR"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("6AqMpWfWxUdoxqbiwAwGG2LjJyEcsq9dHH2Qi4Bxfcot"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("J87LRSm5dAqFVm5G4D91d6HRvTVx8hQR1zP6Lc7HYtpZ"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("4KqauEUpfQpDQF2MJigT6ZZPoTqmgoMf7Yo7PKhy2BZh"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("9sRgpBm8idu7R8u8XnFGCz9enhvrEegZzkQBZkTG6eLY"), true, false),
-		}
-	} else {
-		accountMetas = solana.AccountMetaSlice{
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("J1GyvGaY3XPNGFQkMNx7C5aT48duYRrAJE75Evk3neas"), false, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("HRgVv1pyBLXdsAddq4ubSqo8xdQWRrYbvmXqEDtectce"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("5DT9Vdod6JgqJhdbWSDXt6CaLVAHZB5DjxDjPDQ5rqPv"), true, false),
-			solana.NewAccountMeta(params.FirstToken, false, false),
-			solana.NewAccountMeta(params.SecondToken, false, false),
-			solana.NewAccountMeta(destinationATA, true, false),
-			solana.NewAccountMeta(tempAccount, true, true),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("4AY5LB93QjtsdZ8Ln5M7HJrLkjmxR8xAt6qCwPmTeL8p"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("44wJFmJbZWDbENLAn7PHE29ULZzC8EiBettkT48ePPT7"), true, false),
-			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("D4P9HJYPczLFHvxBgpLKooy7eWczci8pr4x9Zu7iYCVN"), false, false),
-			solana.NewAccountMeta(lifinity.TOKEN_2022_PROGRAM_ID, false, false),
-			solana.NewAccountMeta(TOKEN_PROGRAM_ID, false, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("Dhn3qtRg64qy3zS92Py9km1H1K45C5FyHtfomfF6TLvH"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("8Hd1P8TE3g9daaa1HjJXPDg315FhaGaeptuNvUk3Dw9f"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("5A1JsrPPPpejc3YNfWjeySCEEzG4xJcFS8w2XFdc3LY4"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("HcceiFnd7MV6ZpMVN4sYxeu8xXpFvWEGaRpagYYKEH8K"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("4Ae7GfgJtJGiFF1wosm7SPGifHvsgwdav2mYbAPvkSuH"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("77stihPdVQEMyTJZtpQSyDrmpNxZAHfY8pRE7hoLKk7c"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("44Qp49CisK1QHb78ws2ZenaUu3eH4tRxYfiePZkYd7aT"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("5AsXGNnrQjFoTwqS53kt6Sw9WDtDxE4b3tGcKLZPt19G"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("JBhWCkxJAu6WdPBzxufVSmXWPUVULK3tAvWSuvQa9AfB"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("DkiQZDoEmWMgVbVipzkz8KSsVrikeAGFXBd4Kyjagzh5"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("4b45YauzzMv8W8UruSVBe42yTeap7A7Lidy7nBNJVsWv"), true, false),
-			solana.NewAccountMeta(solana.MustPublicKeyFromBase58("G1oBuHHzf9wtBvgA588RuBbuLjZ7Vwgh3E8jfh29Y33B"), true, false),
-		}
+	for _, tick := range ticks {
+		accountMetas = append(accountMetas, solana.NewAccountMeta(tick, true, false))
 	}
 
 	return solana.NewInstruction(
@@ -166,7 +123,7 @@ func createSwapInstruction(params token.SwapInstructions, tempAccount solana.Pub
 
 func createCloseAccountInstruction(params token.SwapInstructions, tempAccount solana.PublicKey) solana.Instruction {
 	return solana.NewInstruction(
-		TOKEN_PROGRAM_ID,
+		solana.TokenProgramID,
 		solana.AccountMetaSlice{
 			solana.NewAccountMeta(tempAccount, true, true),
 			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
@@ -176,107 +133,166 @@ func createCloseAccountInstruction(params token.SwapInstructions, tempAccount so
 	)
 }
 
-func CreateFullSwapInstructions(params token.SwapInstructions) ([]solana.Instruction, *solana.PrivateKey, error) {
-	newAccountKeypair := solana.NewWallet().PrivateKey
-	newAccountPubkey := newAccountKeypair.PublicKey()
+type Swap struct {
+	Instructions []solana.Instruction
+	Tables       sender.LookupTables
+	TempAccount  *solana.PrivateKey
+	AmountOut    uint64
+}
 
-	createAccountIx := createCreateAccountInstruction(params, newAccountKeypair.PublicKey())
+// BuildSwap builds a swap of params.Amount of params.FirstToken into
+// params.SecondToken through the most liquid Invariant pool of the pair. The
+// price may move by at most slippageBps, and native ETH goes through a
+// temporary wrapped account on either side. With createTable the pool
+// accounts are moved to a lookup table of the payer, created on first use,
+// which leaves room in the transaction for more ticks.
+func BuildSwap(ctx context.Context, client *rpc.Client, params token.SwapInstructions, slippageBps int, createTable bool) (*Swap, error) {
+	payer := params.Payer.PublicKey()
+	eth := configs.MustToken("ETH").Address
+
+	pool, err := FindPool(ctx, client, params.FirstToken, params.SecondToken)
+	if err != nil {
+		return nil, err
+	}
+
+	xToY := params.FirstToken.Equals(pool.TokenX)
+	limit := sqrtPriceLimit(pool.SqrtPrice, xToY, slippageBps)
+
+	swap := &Swap{}
+	var instructions, closeInstructions []solana.Instruction
+	var tokenAccounts, programs [2]solana.PublicKey
+
+	for i, mint := range []solana.PublicKey{pool.TokenX, pool.TokenY} {
+		programs[i], err = token.MintProgram(ctx, client, mint)
+		if err != nil {
+			return nil, err
+		}
+
+		if mint.Equals(eth) {
+			tempAccount := solana.NewWallet().PrivateKey
+			swap.TempAccount = &tempAccount
+			tokenAccounts[i] = tempAccount.PublicKey()
 
-	instructions := []solana.Instruction{
-		createAccountIx,
+			instructions = append(instructions, createCreateAccountInstruction(params, tokenAccounts[i]))
+			if params.FirstToken.Equals(eth) {
+				instructions = append(instructions, createTransferInstruction(params, tokenAccounts[i], params.Amount))
+			}
+			instructions = append(instructions, createInitializeAccountInstruction(params, tokenAccounts[i]))
+			closeInstructions = append(closeInstructions, createCloseAccountInstruction(params, tokenAccounts[i]))
+			continue
+		}
+
+		var createATAIx solana.Instruction
+		tokenAccounts[i], createATAIx, err = token.CreateATAIdempotent(ctx, client, payer, payer, mint)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving token account: %v", err)
+		}
+		instructions = append(instructions, createATAIx)
 	}
 
-	if params.IsETH {
-		instructions = append(instructions, createTransferInstruction(params, newAccountPubkey, params.Amount))
+	swap.Tables = lookupTables(ctx, client, params.Payer, poolAccounts(pool, programs[0], programs[1]), createTable)
+
+	tickmap, err := LoadTickmap(ctx, client, pool)
+	if err != nil {
+		return nil, err
 	}
 
-	instructions = append(instructions,
-		createInitializeAccountInstruction(params, newAccountPubkey),
-		createSwapInstruction(params, newAccountPubkey),
-		createCloseAccountInstruction(params, newAccountPubkey),
-	)
+	// the instruction takes the ticks ordered from the current price, as
+	// many as fit in the transaction, so a swap that needs a tick past them
+	// cannot be sent
+	probe := append(append([]solana.Instruction{}, instructions...), createSwapInstruction(params, pool, xToY, tokenAccounts[0], tokenAccounts[1], programs[0], programs[1], nil, limit))
+	capacity, err := tickCapacity(append(probe, closeInstructions...), payer, swap.Tables)
+	if err != nil {
+		return nil, err
+	}
 
-	return instructions, &newAccountKeypair, nil
-}
+	indexes := swapTicks(pool, tickmap, xToY, limit)
+	if len(indexes) > capacity {
+		indexes = indexes[:capacity]
+	}
 
-func InvariantSendTx(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, feePayer solana.PrivateKey, newAccountKeypair *solana.PrivateKey) (solana.Signature, error) {
-	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	ticks, err := LoadTicks(ctx, client, pool, indexes)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error getting latest blockhash: %v", err)
+		return nil, err
 	}
 
-	tx, err := solana.NewTransaction(
-		instructions,
-		recent.Value.Blockhash,
-		solana.TransactionPayer(feePayer.PublicKey()),
-	)
+	amountOut, crossed, err := simulateSwap(pool, tickmap, ticks, xToY, params.Amount, limit)
+	if errors.Is(err, errTickNotLoaded) {
+		return nil, fmt.Errorf("свап в пуле %s пересекает больше %d тиков, уменьшите сумму", pool.Address, capacity)
+	}
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error creating transaction: %v", err)
+		return nil, fmt.Errorf("не удалось рассчитать свап в пуле %s: %v", pool.Address, err)
+	}
+	if amountOut == 0 {
+		return nil, fmt.Errorf("свап в пуле %s ничего не вернет", pool.Address)
 	}
+	swap.AmountOut = amountOut
 
-	tx.Message.SetVersion(0)
+	logger.Info("Пул Invariant %s: ожидаемый выход %d, пересекает тиков: %d", pool.Address, amountOut, len(crossed))
 
-	for i := range tx.Message.AccountKeys {
-		if tx.Message.AccountKeys[i].Equals(feePayer.PublicKey()) ||
-			tx.Message.AccountKeys[i].Equals(newAccountKeypair.PublicKey()) {
-			tx.Message.Header.NumRequiredSignatures = 2
-			tx.Message.Header.NumReadonlySignedAccounts = 0
-			break
+	// ticks past the crossed ones are passed too, in case the price moves
+	// before the transaction lands
+	tickAccounts := make([]solana.PublicKey, len(indexes))
+	for i, index := range indexes {
+		tickAccounts[i], err = tickAddress(pool.Address, index)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(feePayer.PublicKey()) {
-			return &feePayer
-		}
-		if key.Equals(newAccountKeypair.PublicKey()) {
-			return newAccountKeypair
-		}
-		return nil
-	})
+	instructions = append(instructions, createSwapInstruction(params, pool, xToY, tokenAccounts[0], tokenAccounts[1], programs[0], programs[1], tickAccounts, limit))
+	swap.Instructions = append(instructions, closeInstructions...)
+
+	return swap, nil
+}
+
+// tickCapacity returns how many tick accounts fit in the transaction of
+// instructions, leaving room for the compute budget instructions the fee
+// policy prepends.
+func tickCapacity(instructions []solana.Instruction, payer solana.PublicKey, tables sender.LookupTables) (int, error) {
+	probe := append([]solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(0).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(0).Build(),
+	}, instructions...)
+
+	tx, err := sender.NewTransaction(probe, solana.Hash{}, payer, tables)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error signing transaction: %v", err)
+		return 0, err
 	}
 
-	retrie := uint(10)
-	sig, err := client.SendTransactionWithOpts(ctx, tx,
-		rpc.TransactionOpts{
-			SkipPreflight:       false,
-			PreflightCommitment: rpc.CommitmentConfirmed,
-			MaxRetries:          &retrie,
-			MinContextSlot:      nil,
-		},
-	)
+	size, err := sender.TransactionSize(tx)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error sending transaction: %v", err)
+		return 0, err
 	}
 
-	time.Sleep(time.Second * 2)
+	return (sender.MaxTransactionSize - size) / tickAccountSize, nil
+}
 
-	maxAttempts := 15
-	for i := 0; i < maxAttempts; i++ {
-		response, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
-			Commitment: rpc.CommitmentConfirmed,
-		})
-		if err != nil {
-			if i < maxAttempts-1 {
-				logger.Info("Attempt %d: Waiting for confirmation... (%v)", i+1, err)
-				time.Sleep(time.Second * 3)
-				continue
-			}
-			return sig, fmt.Errorf("transaction failed: %v", err)
-		}
+func InvariantSendTx(ctx context.Context, client *rpc.Client, swap *Swap, feePayer solana.PrivateKey, tracker *sender.Tracker, policy *fees.Policy) (solana.Signature, error) {
+	instructions, err := policy.Apply(ctx, swap.Instructions, feePayer.PublicKey(), swap.Tables)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("error applying priority fee: %v", err)
+	}
 
-		if response != nil {
-			if response.Meta.Err != nil {
-				return sig, fmt.Errorf("transaction failed with error: %v", response.Meta.Err)
-			}
-			logger.Success("Transaction sent succesfully: %s%s", constants.EclipseScan, sig)
-			return sig, nil
-		}
+	var signers []solana.PrivateKey
+	if swap.TempAccount != nil {
+		signers = append(signers, *swap.TempAccount)
+	}
 
-		time.Sleep(time.Second * 3)
+	tx, lastValidBlockHeight, err := sender.BuildTransaction(ctx, client, instructions, feePayer, swap.Tables, signers...)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	opts := sender.DefaultOptions()
+	opts.PreflightCommitment = rpc.CommitmentConfirmed
+	opts.LastValidBlockHeight = lastValidBlockHeight
+	opts.Tracker = tracker
+
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		return result.Signature, fmt.Errorf("error sending transaction: %w", err)
 	}
 
-	return sig, fmt.Errorf("transaction not confirmed after %d attempts", maxAttempts)
+	return result.Signature, nil
 }
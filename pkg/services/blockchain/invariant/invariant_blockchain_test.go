@@ -0,0 +1,53 @@
+package invariant
+
+import (
+	"eclipse/internal/token"
+	"eclipse/pkg/services/blockchain/sender"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+func TestTickCapacity(t *testing.T) {
+	key := func() solana.PublicKey { return solana.NewWallet().PublicKey() }
+
+	params := token.SwapInstructions{Payer: solana.NewWallet().PrivateKey, Amount: 1}
+	payer := params.Payer.PublicKey()
+	pool := &Pool{Address: key(), TokenX: key(), TokenY: key(), ReserveX: key(), ReserveY: key(), Tickmap: key()}
+	accountX, accountY := key(), key()
+
+	swapWithTicks := func(n int) []solana.Instruction {
+		ticks := make([]solana.PublicKey, n)
+		for i := range ticks {
+			ticks[i] = key()
+		}
+		return []solana.Instruction{
+			computebudget.NewSetComputeUnitLimitInstruction(200_000).Build(),
+			computebudget.NewSetComputeUnitPriceInstruction(1_000).Build(),
+			createSwapInstruction(params, pool, true, accountX, accountY, solana.TokenProgramID, solana.Token2022ProgramID, ticks, sqrtPriceAtTick(0)),
+		}
+	}
+
+	withTable := sender.LookupTables{key(): poolAccounts(pool, solana.TokenProgramID, solana.Token2022ProgramID)}
+
+	capacities := make(map[bool]int)
+	for _, tables := range []sender.LookupTables{nil, withTable} {
+		capacity, err := tickCapacity(swapWithTicks(0)[2:], payer, tables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		capacities[tables != nil] = capacity
+
+		if _, err := sender.NewTransaction(swapWithTicks(capacity), solana.Hash{}, payer, tables); err != nil {
+			t.Errorf("%d ticks do not fit (table %t): %v", capacity, tables != nil, err)
+		}
+		if _, err := sender.NewTransaction(swapWithTicks(capacity+1), solana.Hash{}, payer, tables); err == nil {
+			t.Errorf("%d ticks fit (table %t), capacity is too low", capacity+1, tables != nil)
+		}
+	}
+
+	if capacities[true] <= capacities[false] {
+		t.Errorf("capacity with table = %d, without = %d, want more with the table", capacities[true], capacities[false])
+	}
+}
@@ -2,45 +2,49 @@
 
 import (
 	"context"
-	"database/sql"
 	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/base"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
-	"eclipse/pkg/services/blockchain/lifinity"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
 	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/randomizer"
-	"eclipse/pkg/services/telegram"
 	"eclipse/utils/balance"
 	"fmt"
-	"net/http"
 	"strconv"
 	"time"
-
-	"github.com/gagliardetto/solana-go/rpc"
-)
-
-const (
-	WRAPPED_ETH_ADDRESS = "So11111111111111111111111111111111111111112"
 )
 
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	httpClient http.Client,
-	rpcClient *rpc.Client,
-	cfg configs.AppConfig,
-	acc *model.EclipseAccount,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Invariant",
+		ConfigKey: "invariant",
+		IsSwap:    true,
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Invariant Swap")
 
+	cfg := exec.Config
+	acc := exec.EclipseAccount
+	rpcClient := exec.RpcClient
+	notifier := exec.Notifier
+	db := exec.DB
+	maxAttempts := exec.MaxAttempts()
+	tracker := sender.NewTracker(rpcClient)
+	policy := exec.FeePolicy()
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		var amountDecimals uint64
 		var err error
 
@@ -58,8 +62,8 @@ func (m *Module) Execute(
 
 			params := token.SwapInstructions{
 				Payer:         acc.PrivateKey,
-				FirstToken:    lifinity.USDC,
-				SecondToken:   lifinity.WETH,
+				FirstToken:    configs.MustToken("USDC").Address,
+				SecondToken:   configs.MustToken("ETH").Address,
 				Amount:        amountDecimals,
 				IsETH:         false,
 				TokenSymbol:   "USDC",
@@ -72,15 +76,24 @@ func (m *Module) Execute(
 				continue
 			}
 
-			instructions, newAccountKeypair, err := CreateFullSwapInstructions(params)
+			swap, err := BuildSwap(ctx, rpcClient, params, cfg.Slippage.Invariant, true)
 			if err != nil {
-				return false, fmt.Errorf("error creating instructions: %v", err)
+				logger.Error("Не удалось построить свап (попытка %d/%d): %v", attempt+1, maxAttempts, err)
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
+				continue
 			}
 
-			sig, err := InvariantSendTx(ctx, rpcClient, instructions, params.Payer, newAccountKeypair)
+			sig, err := InvariantSendTx(ctx, rpcClient, swap, params.Payer, tracker, policy)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			}
 			amount := float64(amountDecimals) / 1_000_000
@@ -107,7 +120,10 @@ func (m *Module) Execute(
 			)
 			return true, nil
 		} else {
-			firstPair, secondPair, tokenType, err := base.GetRandomTokenPair(cfg.Invariant.Tokens)
+			firstPair, secondPair, tokenType, err := base.GetRandomPoolPair(cfg.Invariant.Tokens, func(first, second configs.Token) error {
+				_, err := FindPool(ctx, rpcClient, first.Address, second.Address)
+				return err
+			})
 			if err != nil {
 				return false, fmt.Errorf("error getting token pair: %v", err)
 			}
@@ -149,7 +165,7 @@ func (m *Module) Execute(
 				return false, fmt.Errorf("error parsing value string: %v", err)
 			}
 
-			isETH := firstPair.Address.String() == WRAPPED_ETH_ADDRESS
+			isETH := firstPair.Address.Equals(configs.MustToken("ETH").Address)
 
 			logger.Info("Пытаюсь выполнить свап %f %s -> %s", value, firstPair.Symbol, secondPair.Symbol)
 
@@ -169,15 +185,24 @@ func (m *Module) Execute(
 				continue
 			}
 
-			instructions, newAccountKeypair, err := CreateFullSwapInstructions(params)
+			swap, err := BuildSwap(ctx, rpcClient, params, cfg.Slippage.Invariant, true)
 			if err != nil {
-				return false, fmt.Errorf("error creating instructions: %v", err)
+				logger.Error("Не удалось построить свап (попытка %d/%d): %v", attempt+1, maxAttempts, err)
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
+				continue
 			}
 
-			sig, err := InvariantSendTx(ctx, rpcClient, instructions, params.Payer, newAccountKeypair)
+			sig, err := InvariantSendTx(ctx, rpcClient, swap, params.Payer, tracker, policy)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			} else {
 				if db != nil && cfg.Database.Enabled {
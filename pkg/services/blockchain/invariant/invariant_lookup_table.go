@@ -0,0 +1,177 @@
+package invariant
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"eclipse/pkg/services/blockchain/sender"
+	"eclipse/pkg/services/randomizer"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// The lookup tables of a wallet are looked up once per run and then kept up
+// to date as pools are added to them. A wallet is only used by one thread.
+var (
+	walletTables   = make(map[solana.PublicKey]sender.LookupTables)
+	walletTablesMu sync.Mutex
+)
+
+// tableActivationTimeout bounds the wait for addresses added to a lookup
+// table to become usable, which happens in the slot after they land.
+const tableActivationTimeout = 30 * time.Second
+
+// poolAccounts are the accounts of a swap in pool that never change, so they
+// can be kept in a lookup table instead of the transaction.
+func poolAccounts(pool *Pool, programX, programY solana.PublicKey) []solana.PublicKey {
+	accounts := solana.PublicKeySlice{
+		INVARIANT_STATE,
+		INVARIANT_AUTHORITY,
+		pool.Address,
+		pool.Tickmap,
+		pool.TokenX,
+		pool.TokenY,
+		pool.ReserveX,
+		pool.ReserveY,
+	}
+	accounts.UniqueAppend(programX)
+	accounts.UniqueAppend(programY)
+	return accounts
+}
+
+// lookupTables returns the lookup tables of payer. With create set, the
+// accounts missing from them are added to a table of payer, a new one when
+// none has room, through a separate transaction. When that is not possible
+// the swap is built without them and fits fewer ticks.
+func lookupTables(ctx context.Context, client *rpc.Client, payer solana.PrivateKey, accounts []solana.PublicKey, create bool) sender.LookupTables {
+	authority := payer.PublicKey()
+
+	walletTablesMu.Lock()
+	tables, cached := walletTables[authority]
+	walletTablesMu.Unlock()
+
+	if !cached {
+		var err error
+		tables, err = sender.FindLookupTables(ctx, client, authority)
+		if err != nil {
+			// without knowing the existing tables a new one would be
+			// created on every swap
+			logger.Warning("Свап Invariant будет собран без lookup таблицы: %v", err)
+			return nil
+		}
+
+		walletTablesMu.Lock()
+		walletTables[authority] = tables
+		walletTablesMu.Unlock()
+	}
+
+	missing := missingAccounts(tables, accounts)
+	if len(missing) == 0 || !create {
+		return tables
+	}
+	if sender.IsDryRun(ctx) {
+		logger.Info("[dry-run] Lookup таблица для пула не создается, аккаунты пула останутся в транзакции")
+		return tables
+	}
+
+	table, err := extendLookupTable(ctx, client, payer, tables, missing)
+	if err != nil {
+		logger.Warning("Не удалось добавить аккаунты пула в lookup таблицу, свап будет собран без нее: %v", err)
+
+		// the table may have changed anyway, read it again next time
+		walletTablesMu.Lock()
+		delete(walletTables, authority)
+		walletTablesMu.Unlock()
+		return tables
+	}
+
+	extended := make(sender.LookupTables, len(tables)+1)
+	for address, held := range tables {
+		extended[address] = held
+	}
+	extended[table] = append(append(solana.PublicKeySlice{}, tables[table]...), missing...)
+
+	walletTablesMu.Lock()
+	walletTables[authority] = extended
+	walletTablesMu.Unlock()
+
+	return extended
+}
+
+func missingAccounts(tables sender.LookupTables, accounts []solana.PublicKey) []solana.PublicKey {
+	var missing solana.PublicKeySlice
+	for _, account := range accounts {
+		held := false
+		for _, addresses := range tables {
+			if addresses.Contains(account) {
+				held = true
+				break
+			}
+		}
+		if !held {
+			missing.UniqueAppend(account)
+		}
+	}
+	return missing
+}
+
+// extendLookupTable adds addresses to a table of payer with room for them,
+// creating one when there is none, and waits until they can be looked up.
+func extendLookupTable(ctx context.Context, client *rpc.Client, payer solana.PrivateKey, tables sender.LookupTables, addresses []solana.PublicKey) (solana.PublicKey, error) {
+	authority := payer.PublicKey()
+
+	var table solana.PublicKey
+	for address, held := range tables {
+		if len(held)+len(addresses) <= sender.MaxLookupTableAddresses {
+			table = address
+			break
+		}
+	}
+
+	var instructions []solana.Instruction
+	if table.IsZero() {
+		slot, err := client.GetSlot(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return solana.PublicKey{}, fmt.Errorf("error getting slot: %v", err)
+		}
+
+		var create solana.Instruction
+		create, table, err = sender.CreateLookupTableInstruction(authority, authority, slot)
+		if err != nil {
+			return solana.PublicKey{}, err
+		}
+		instructions = append(instructions, create)
+		logger.Info("Создаю lookup таблицу %s для аккаунтов пулов Invariant", table)
+	}
+	instructions = append(instructions, sender.ExtendLookupTableInstruction(table, authority, authority, addresses))
+
+	tx, lastValidBlockHeight, err := sender.BuildTransaction(ctx, client, instructions, payer, nil)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	opts := sender.DefaultOptions()
+	opts.LastValidBlockHeight = lastValidBlockHeight
+
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("error sending transaction: %w", err)
+	}
+
+	deadline := time.Now().Add(tableActivationTimeout)
+	for {
+		slot, err := client.GetSlot(ctx, rpc.CommitmentConfirmed)
+		if err == nil && slot > result.Slot {
+			return table, nil
+		}
+		if time.Now().After(deadline) {
+			return solana.PublicKey{}, fmt.Errorf("lookup таблица %s не активировалась за %s", table, tableActivationTimeout)
+		}
+		if err := randomizer.Sleep(ctx, 400*time.Millisecond); err != nil {
+			return solana.PublicKey{}, err
+		}
+	}
+}
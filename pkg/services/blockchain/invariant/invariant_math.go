@@ -0,0 +1,339 @@
+package invariant
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Fixed point scales used by the program: sqrt prices have 24 decimals,
+// liquidity 6 and the pool fee 12.
+var (
+	priceDenominator     = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+	liquidityDenominator = new(big.Int).Exp(big.NewInt(10), big.NewInt(6), nil)
+	feeDenominator       = new(big.Int).Exp(big.NewInt(10), big.NewInt(12), nil)
+)
+
+// maxSwapSteps bounds the swap simulation, a swap inside the slippage range
+// never needs more.
+const maxSwapSteps = 256
+
+// errTickNotLoaded is returned by simulateSwap when the swap reaches an
+// initialized tick missing from the loaded ones.
+var errTickNotLoaded = errors.New("тик не загружен")
+
+// ErrInsufficientLiquidity is returned by simulateSwap when the pool cannot
+// fill the amount before the price reaches the slippage limit.
+var ErrInsufficientLiquidity = errors.New("недостаточно ликвидности в пределах проскальзывания")
+
+// sqrtTickBase is parsed from a string, 1.0001 has no exact float64 form
+// and the error would grow with the tick.
+var sqrtTickBase = func() *big.Float {
+	base, _ := new(big.Float).SetPrec(256).SetString("1.0001")
+	return base.Sqrt(base)
+}()
+
+// sqrtPriceAtTick returns sqrt(1.0001^tick) scaled by the price denominator.
+func sqrtPriceAtTick(tick int32) *big.Int {
+	exp := tick
+	if exp < 0 {
+		exp = -exp
+	}
+
+	result := new(big.Float).SetPrec(256).SetInt64(1)
+	base := new(big.Float).SetPrec(256).Set(sqrtTickBase)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+		exp >>= 1
+	}
+
+	if tick < 0 {
+		result.Quo(new(big.Float).SetPrec(256).SetInt64(1), result)
+	}
+
+	price, _ := result.Mul(result, new(big.Float).SetPrec(256).SetInt(priceDenominator)).Int(nil)
+	return price
+}
+
+// tickAtSqrtPrice returns the highest tick aligned to spacing whose price
+// does not exceed sqrtPrice.
+func tickAtSqrtPrice(sqrtPrice *big.Int, spacing int32) int32 {
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(sqrtPrice), new(big.Float).SetInt(priceDenominator)).Float64()
+
+	tick := int32(math.Floor(2 * math.Log(ratio) / math.Log(1.0001)))
+	for tick < maxTick && sqrtPriceAtTick(tick+1).Cmp(sqrtPrice) <= 0 {
+		tick++
+	}
+	for tick > -maxTick && sqrtPriceAtTick(tick).Cmp(sqrtPrice) > 0 {
+		tick--
+	}
+
+	return alignTick(tick, spacing)
+}
+
+func alignTick(tick, spacing int32) int32 {
+	rem := tick % spacing
+	if rem < 0 {
+		rem += spacing
+	}
+	return tick - rem
+}
+
+func isInitialized(tickmap []byte, tick, spacing int32) bool {
+	position := tick/spacing + tickLimit
+	if position < 0 || int(position/8) >= len(tickmap) {
+		return false
+	}
+	return tickmap[position/8]&(1<<(position%8)) != 0
+}
+
+func searchLimit(tick, spacing int32, up bool) int32 {
+	index := tick / spacing
+	if up {
+		limit := index + tickSearchRange
+		limit = min(limit, tickLimit-1, maxTick/spacing)
+		return limit * spacing
+	}
+
+	limit := index - tickSearchRange
+	limit = max(limit, -tickLimit+1, -maxTick/spacing)
+	return limit * spacing
+}
+
+// nextInitialized finds the first initialized tick above tick within the
+// program's search range.
+func nextInitialized(tickmap []byte, tick, spacing int32) (int32, bool) {
+	limit := searchLimit(tick, spacing, true)
+	for t := tick + spacing; t <= limit; t += spacing {
+		if isInitialized(tickmap, t, spacing) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// prevInitialized finds the first initialized tick at or below tick within
+// the program's search range.
+func prevInitialized(tickmap []byte, tick, spacing int32) (int32, bool) {
+	limit := searchLimit(tick, spacing, false)
+	for t := tick; t >= limit; t -= spacing {
+		if isInitialized(tickmap, t, spacing) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// closerLimit mirrors the program's choice of the next swap step target: the
+// closest initialized tick, the edge of the search range or the price limit.
+func closerLimit(tickmap []byte, sqrtPriceLimit *big.Int, xToY bool, current, spacing int32) (*big.Int, int32, bool, bool) {
+	index, initialized := prevInitialized(tickmap, current, spacing)
+	if !xToY {
+		index, initialized = nextInitialized(tickmap, current, spacing)
+	}
+	if !initialized {
+		index = searchLimit(current, spacing, !xToY)
+	}
+
+	price := sqrtPriceAtTick(index)
+	if (xToY && price.Cmp(sqrtPriceLimit) > 0) || (!xToY && price.Cmp(sqrtPriceLimit) < 0) {
+		return price, index, initialized, true
+	}
+
+	return sqrtPriceLimit, 0, false, false
+}
+
+func divUp(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+func div(a, b *big.Int, up bool) *big.Int {
+	if up {
+		return divUp(a, b)
+	}
+	return new(big.Int).Quo(a, b)
+}
+
+// deltaX is the amount of x between two sqrt prices: L * (b - a) / (a * b).
+func deltaX(a, b, liquidity *big.Int, up bool) *big.Int {
+	if a.Cmp(b) > 0 {
+		a, b = b, a
+	}
+
+	num := new(big.Int).Mul(liquidity, new(big.Int).Sub(b, a))
+	num.Mul(num, priceDenominator)
+	den := new(big.Int).Mul(a, b)
+	den.Mul(den, liquidityDenominator)
+
+	return div(num, den, up)
+}
+
+// deltaY is the amount of y between two sqrt prices: L * (b - a).
+func deltaY(a, b, liquidity *big.Int, up bool) *big.Int {
+	if a.Cmp(b) > 0 {
+		a, b = b, a
+	}
+
+	num := new(big.Int).Mul(liquidity, new(big.Int).Sub(b, a))
+	den := new(big.Int).Mul(priceDenominator, liquidityDenominator)
+
+	return div(num, den, up)
+}
+
+// nextSqrtPrice moves sqrtPrice by an input amount, down for x and up for y,
+// rounding against the trader.
+func nextSqrtPrice(sqrtPrice, liquidity, amount *big.Int, xToY bool) *big.Int {
+	if xToY {
+		num := new(big.Int).Mul(liquidity, sqrtPrice)
+		num.Mul(num, priceDenominator)
+		den := new(big.Int).Mul(liquidity, priceDenominator)
+		den.Add(den, new(big.Int).Mul(new(big.Int).Mul(amount, sqrtPrice), liquidityDenominator))
+		return divUp(num, den)
+	}
+
+	num := new(big.Int).Mul(amount, priceDenominator)
+	num.Mul(num, liquidityDenominator)
+	return new(big.Int).Add(sqrtPrice, new(big.Int).Quo(num, liquidity))
+}
+
+type swapStep struct {
+	next      *big.Int
+	amountIn  *big.Int
+	amountOut *big.Int
+	feeAmount *big.Int
+}
+
+func computeSwapStep(current, target, liquidity, amount, fee *big.Int) swapStep {
+	xToY := current.Cmp(target) >= 0
+
+	if liquidity.Sign() == 0 {
+		return swapStep{next: target, amountIn: new(big.Int), amountOut: new(big.Int), feeAmount: new(big.Int)}
+	}
+
+	afterFee := new(big.Int).Mul(amount, new(big.Int).Sub(feeDenominator, fee))
+	afterFee.Quo(afterFee, feeDenominator)
+
+	amountIn := deltaY(current, target, liquidity, true)
+	if xToY {
+		amountIn = deltaX(target, current, liquidity, true)
+	}
+
+	next := target
+	if afterFee.Cmp(amountIn) < 0 {
+		next = nextSqrtPrice(current, liquidity, afterFee, xToY)
+	}
+	reachedTarget := next.Cmp(target) == 0
+
+	if !reachedTarget {
+		amountIn = deltaY(current, next, liquidity, true)
+		if xToY {
+			amountIn = deltaX(next, current, liquidity, true)
+		}
+	}
+
+	amountOut := deltaX(current, next, liquidity, false)
+	if xToY {
+		amountOut = deltaY(next, current, liquidity, false)
+	}
+
+	var feeAmount *big.Int
+	if reachedTarget {
+		feeAmount = divUp(new(big.Int).Mul(amountIn, fee), feeDenominator)
+	} else {
+		feeAmount = new(big.Int).Sub(amount, amountIn)
+	}
+
+	return swapStep{next: next, amountIn: amountIn, amountOut: amountOut, feeAmount: feeAmount}
+}
+
+// swapTicks returns the initialized ticks between the current tick and the
+// price limit in the order a swap would reach them.
+func swapTicks(pool *Pool, tickmap []byte, xToY bool, sqrtPriceLimit *big.Int) []int32 {
+	limitTick := tickAtSqrtPrice(sqrtPriceLimit, pool.TickSpacing)
+
+	var ticks []int32
+	if xToY {
+		for t := pool.CurrentTick; t >= limitTick; t -= pool.TickSpacing {
+			if isInitialized(tickmap, t, pool.TickSpacing) {
+				ticks = append(ticks, t)
+			}
+		}
+	} else {
+		for t := pool.CurrentTick + pool.TickSpacing; t <= limitTick+pool.TickSpacing; t += pool.TickSpacing {
+			if isInitialized(tickmap, t, pool.TickSpacing) {
+				ticks = append(ticks, t)
+			}
+		}
+	}
+
+	return ticks
+}
+
+// simulateSwap replays the program's swap loop on the loaded state and
+// returns the output amount and the initialized ticks the swap crosses.
+func simulateSwap(pool *Pool, tickmap []byte, ticks map[int32]Tick, xToY bool, amount uint64, sqrtPriceLimit *big.Int) (uint64, []int32, error) {
+	remaining := new(big.Int).SetUint64(amount)
+	amountOut := new(big.Int)
+	sqrtPrice := new(big.Int).Set(pool.SqrtPrice)
+	liquidity := new(big.Int).Set(pool.Liquidity)
+	current := pool.CurrentTick
+
+	var crossed []int32
+	for step := 0; remaining.Sign() > 0; step++ {
+		if step >= maxSwapSteps {
+			return 0, nil, fmt.Errorf("свап не уложился в %d шагов", maxSwapSteps)
+		}
+
+		target, index, initialized, limited := closerLimit(tickmap, sqrtPriceLimit, xToY, current, pool.TickSpacing)
+
+		result := computeSwapStep(sqrtPrice, target, liquidity, remaining, pool.Fee)
+		remaining.Sub(remaining, result.amountIn)
+		remaining.Sub(remaining, result.feeAmount)
+		amountOut.Add(amountOut, result.amountOut)
+		sqrtPrice = result.next
+
+		if sqrtPrice.Cmp(sqrtPriceLimit) == 0 && remaining.Sign() > 0 {
+			return 0, nil, ErrInsufficientLiquidity
+		}
+
+		if limited && sqrtPrice.Cmp(target) == 0 {
+			if initialized {
+				tick, ok := ticks[index]
+				if !ok {
+					return 0, nil, fmt.Errorf("%w: %d", errTickNotLoaded, index)
+				}
+				crossed = append(crossed, index)
+
+				if (xToY && tick.Sign) || (!xToY && !tick.Sign) {
+					liquidity.Sub(liquidity, tick.LiquidityChange)
+				} else {
+					liquidity.Add(liquidity, tick.LiquidityChange)
+				}
+				if liquidity.Sign() < 0 {
+					return 0, nil, fmt.Errorf("отрицательная ликвидность после тика %d", index)
+				}
+			}
+
+			current = index
+			if xToY {
+				current = index - pool.TickSpacing
+			}
+		} else {
+			current = tickAtSqrtPrice(sqrtPrice, pool.TickSpacing)
+		}
+	}
+
+	if !amountOut.IsUint64() {
+		return 0, nil, fmt.Errorf("слишком большой выход свапа")
+	}
+
+	return amountOut.Uint64(), crossed, nil
+}
@@ -0,0 +1,164 @@
+package invariant
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSqrtPriceAtTick(t *testing.T) {
+	tests := []struct {
+		tick int32
+		want string
+	}{
+		{0, "1000000000000000000000000"},
+		{1, "1000049998750062496094023"},
+		{-1, "999950003749687527341289"},
+		{10, "1000500100010000500010000"},
+		{-10, "999500149965006998740209"},
+		{1000, "1051268468376766590652765"},
+		{-1000, "951231802418721111001888"},
+		{50000, "12180971345630635666165580"},
+		{-50000, "82095259205966698013081"},
+		{maxTick, "65535384161610681941079229738"},
+		{-maxTick, "15258932449895975601"},
+	}
+
+	for _, tt := range tests {
+		want, _ := new(big.Int).SetString(tt.want, 10)
+		if got := sqrtPriceAtTick(tt.tick); got.Cmp(want) != 0 {
+			t.Errorf("sqrtPriceAtTick(%d) = %s, want %s", tt.tick, got, want)
+		}
+	}
+}
+
+func TestTickAtSqrtPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		sqrtPrice *big.Int
+		spacing   int32
+		want      int32
+	}{
+		{name: "exact tick", sqrtPrice: sqrtPriceAtTick(100), spacing: 1, want: 100},
+		{name: "just below tick", sqrtPrice: new(big.Int).Sub(sqrtPriceAtTick(100), big.NewInt(1)), spacing: 1, want: 99},
+		{name: "aligned down", sqrtPrice: sqrtPriceAtTick(105), spacing: 10, want: 100},
+		{name: "negative aligned down", sqrtPrice: sqrtPriceAtTick(-105), spacing: 10, want: -110},
+		{name: "negative exact", sqrtPrice: sqrtPriceAtTick(-110), spacing: 10, want: -110},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tickAtSqrtPrice(tt.sqrtPrice, tt.spacing); got != tt.want {
+				t.Errorf("tickAtSqrtPrice() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// testPool is a pool at tick 0 with a 0.01% fee, ticks spaced by 10 and the
+// given ticks initialized.
+func testPool(liquidity int64, initialized ...int32) (*Pool, []byte) {
+	const spacing = 10
+
+	tickmap := make([]byte, tickmapSize)
+	for _, tick := range initialized {
+		position := tick/spacing + tickLimit
+		tickmap[position/8] |= 1 << (position % 8)
+	}
+
+	pool := &Pool{
+		TickSpacing: spacing,
+		Fee:         big.NewInt(100_000_000),
+		Liquidity:   new(big.Int).Mul(big.NewInt(liquidity), liquidityDenominator),
+		SqrtPrice:   sqrtPriceAtTick(0),
+		CurrentTick: 0,
+	}
+	return pool, tickmap
+}
+
+func TestSimulateSwap(t *testing.T) {
+	half := new(big.Int).Mul(big.NewInt(500_000_000_000), liquidityDenominator)
+
+	tests := []struct {
+		name        string
+		liquidity   int64
+		initialized []int32
+		ticks       map[int32]Tick
+		xToY        bool
+		amount      uint64
+		wantOut     uint64
+		wantCrossed []int32
+		wantErr     error
+	}{
+		{
+			name:      "x to y within a tick",
+			liquidity: 1_000_000_000_000,
+			xToY:      true,
+			amount:    1_000_000,
+			wantOut:   999_899,
+		},
+		{
+			name:      "y to x within a tick",
+			liquidity: 1_000_000_000_000,
+			xToY:      false,
+			amount:    1_000_000,
+			wantOut:   999_899,
+		},
+		{
+			name:        "crosses an initialized tick",
+			liquidity:   1_000_000_000_000,
+			initialized: []int32{-10},
+			ticks:       map[int32]Tick{-10: {Index: -10, Sign: true, LiquidityChange: half}},
+			xToY:        true,
+			amount:      1_000_000_000,
+			wantOut:     998_652_148,
+			wantCrossed: []int32{-10},
+		},
+		{
+			name:        "tick not loaded",
+			liquidity:   1_000_000_000_000,
+			initialized: []int32{-10},
+			xToY:        true,
+			amount:      1_000_000_000,
+			wantErr:     errTickNotLoaded,
+		},
+		{
+			name:      "not enough liquidity",
+			liquidity: 1_000_000,
+			xToY:      true,
+			amount:    1_000_000_000,
+			wantErr:   ErrInsufficientLiquidity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, tickmap := testPool(tt.liquidity, tt.initialized...)
+			limit := sqrtPriceLimit(pool.SqrtPrice, tt.xToY, 50)
+
+			out, crossed, err := simulateSwap(pool, tickmap, tt.ticks, tt.xToY, tt.amount, limit)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out != tt.wantOut {
+				t.Errorf("out = %d, want %d", out, tt.wantOut)
+			}
+			if len(crossed) != len(tt.wantCrossed) {
+				t.Fatalf("crossed = %v, want %v", crossed, tt.wantCrossed)
+			}
+			for i := range crossed {
+				if crossed[i] != tt.wantCrossed[i] {
+					t.Errorf("crossed = %v, want %v", crossed, tt.wantCrossed)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package invariant
+
+import (
+	"bytes"
+	"context"
+	"eclipse/internal/logger"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Offsets into the packed Pool account, after the 8 byte discriminator.
+const (
+	poolTokenXOffset      = 8
+	poolTokenYOffset      = 40
+	poolReserveXOffset    = 72
+	poolReserveYOffset    = 104
+	poolTickSpacingOffset = 152
+	poolFeeOffset         = 154
+	poolLiquidityOffset   = 186
+	poolSqrtPriceOffset   = 202
+	poolCurrentTickOffset = 218
+	poolTickmapOffset     = 222
+	poolMinSize           = poolTickmapOffset + 32
+)
+
+// Offsets into the packed Tick account.
+const (
+	tickPoolOffset            = 8
+	tickIndexOffset           = 40
+	tickSignOffset            = 44
+	tickLiquidityChangeOffset = 45
+	tickMinSize               = tickLiquidityChangeOffset + 16
+)
+
+const (
+	tickLimit       = 44_364
+	maxTick         = 221_818
+	tickSearchRange = 256
+	tickmapSize     = (2*tickLimit - 1 + 7) / 8
+)
+
+type Pool struct {
+	Address     solana.PublicKey
+	TokenX      solana.PublicKey
+	TokenY      solana.PublicKey
+	ReserveX    solana.PublicKey
+	ReserveY    solana.PublicKey
+	Tickmap     solana.PublicKey
+	TickSpacing int32
+	Fee         *big.Int
+	Liquidity   *big.Int
+	SqrtPrice   *big.Int
+	CurrentTick int32
+}
+
+type Tick struct {
+	Index           int32
+	Sign            bool
+	LiquidityChange *big.Int
+}
+
+func decodePool(address solana.PublicKey, data []byte) (*Pool, error) {
+	if len(data) < poolMinSize {
+		return nil, fmt.Errorf("неожиданный размер пула %s: %d", address, len(data))
+	}
+
+	key := func(offset int) solana.PublicKey {
+		return solana.PublicKeyFromBytes(data[offset : offset+32])
+	}
+
+	pool := &Pool{
+		Address:     address,
+		TokenX:      key(poolTokenXOffset),
+		TokenY:      key(poolTokenYOffset),
+		ReserveX:    key(poolReserveXOffset),
+		ReserveY:    key(poolReserveYOffset),
+		Tickmap:     key(poolTickmapOffset),
+		TickSpacing: int32(binary.LittleEndian.Uint16(data[poolTickSpacingOffset:])),
+		Fee:         readUint128(data[poolFeeOffset : poolFeeOffset+16]),
+		Liquidity:   readUint128(data[poolLiquidityOffset : poolLiquidityOffset+16]),
+		SqrtPrice:   readUint128(data[poolSqrtPriceOffset : poolSqrtPriceOffset+16]),
+		CurrentTick: int32(binary.LittleEndian.Uint32(data[poolCurrentTickOffset:])),
+	}
+
+	if pool.TickSpacing <= 0 || pool.SqrtPrice.Sign() == 0 || pool.CurrentTick%pool.TickSpacing != 0 {
+		return nil, fmt.Errorf("неожиданный формат пула %s", address)
+	}
+
+	return pool, nil
+}
+
+// Pool addresses are looked up once per run and shared by all accounts, the
+// pool state itself is always read fresh.
+var (
+	poolAddresses   = make(map[[2]solana.PublicKey][]solana.PublicKey)
+	poolAddressesMu sync.Mutex
+)
+
+func pairKey(a, b solana.PublicKey) [2]solana.PublicKey {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return [2]solana.PublicKey{a, b}
+}
+
+// FindPool loads every Invariant pool of the mint pair and returns the one
+// with the most active liquidity.
+func FindPool(ctx context.Context, client *rpc.Client, mintA, mintB solana.PublicKey) (*Pool, error) {
+	key := pairKey(mintA, mintB)
+
+	poolAddressesMu.Lock()
+	addresses, cached := poolAddresses[key]
+	poolAddressesMu.Unlock()
+
+	if !cached {
+		var err error
+		addresses, err = discoverPools(ctx, client, key[0], key[1])
+		if err != nil {
+			return nil, err
+		}
+
+		poolAddressesMu.Lock()
+		poolAddresses[key] = addresses
+		poolAddressesMu.Unlock()
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("пул Invariant для пары %s/%s не найден", mintA, mintB)
+	}
+
+	res, err := client.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentProcessed,
+		Encoding:   solana.EncodingBase64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить пулы Invariant: %v", err)
+	}
+
+	var best *Pool
+	for i, account := range res.Value {
+		if account == nil || i >= len(addresses) {
+			continue
+		}
+
+		pool, err := decodePool(addresses[i], account.Data.GetBinary())
+		if err != nil {
+			logger.Warning("Пропускаю пул Invariant: %v", err)
+			continue
+		}
+
+		if best == nil || pool.Liquidity.Cmp(best.Liquidity) > 0 {
+			best = pool
+		}
+	}
+
+	if best == nil {
+		poolAddressesMu.Lock()
+		delete(poolAddresses, key)
+		poolAddressesMu.Unlock()
+		return nil, fmt.Errorf("не удалось прочитать пулы Invariant для пары %s/%s", mintA, mintB)
+	}
+
+	return best, nil
+}
+
+// discoverPools returns the pools of a pair. The program keeps the pair
+// ordered, so both orders are queried.
+func discoverPools(ctx context.Context, client *rpc.Client, mintA, mintB solana.PublicKey) ([]solana.PublicKey, error) {
+	var addresses []solana.PublicKey
+
+	for _, pair := range [][2]solana.PublicKey{{mintA, mintB}, {mintB, mintA}} {
+		accounts, err := client.GetProgramAccountsWithOpts(ctx, INVARIANT_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
+			Commitment: rpc.CommitmentConfirmed,
+			Encoding:   solana.EncodingBase64,
+			DataSlice:  &rpc.DataSlice{Offset: new(uint64), Length: new(uint64)},
+			Filters: []rpc.RPCFilter{
+				{Memcmp: &rpc.RPCFilterMemcmp{Offset: poolTokenXOffset, Bytes: pair[0].Bytes()}},
+				{Memcmp: &rpc.RPCFilterMemcmp{Offset: poolTokenYOffset, Bytes: pair[1].Bytes()}},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка поиска пулов Invariant: %v", err)
+		}
+
+		for _, account := range accounts {
+			addresses = append(addresses, account.Pubkey)
+		}
+	}
+
+	return addresses, nil
+}
+
+// LoadTickmap returns the bitmap of initialized ticks of the pool.
+func LoadTickmap(ctx context.Context, client *rpc.Client, pool *Pool) ([]byte, error) {
+	account, err := client.GetAccountInfoWithOpts(ctx, pool.Tickmap, &rpc.GetAccountInfoOpts{
+		Commitment: rpc.CommitmentProcessed,
+		Encoding:   solana.EncodingBase64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить tickmap %s: %v", pool.Tickmap, err)
+	}
+
+	data := account.Value.Data.GetBinary()
+	if len(data) < 8+tickmapSize {
+		return nil, fmt.Errorf("неожиданный размер tickmap %s: %d", pool.Tickmap, len(data))
+	}
+
+	return data[8 : 8+tickmapSize], nil
+}
+
+func tickAddress(pool solana.PublicKey, index int32) (solana.PublicKey, error) {
+	indexBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexBytes, uint32(index))
+
+	address, _, err := solana.FindProgramAddress([][]byte{
+		[]byte("tickv1"),
+		pool.Bytes(),
+		indexBytes,
+	}, INVARIANT_PROGRAM_ID)
+
+	return address, err
+}
+
+// LoadTicks reads the tick accounts of the given indexes.
+func LoadTicks(ctx context.Context, client *rpc.Client, pool *Pool, indexes []int32) (map[int32]Tick, error) {
+	ticks := make(map[int32]Tick, len(indexes))
+	if len(indexes) == 0 {
+		return ticks, nil
+	}
+
+	addresses := make([]solana.PublicKey, len(indexes))
+	for i, index := range indexes {
+		address, err := tickAddress(pool.Address, index)
+		if err != nil {
+			return nil, err
+		}
+		addresses[i] = address
+	}
+
+	res, err := client.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentProcessed,
+		Encoding:   solana.EncodingBase64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить тики пула: %v", err)
+	}
+
+	for i, account := range res.Value {
+		if i >= len(indexes) {
+			break
+		}
+		if account == nil {
+			return nil, fmt.Errorf("тик %d пула %s не найден", indexes[i], pool.Address)
+		}
+
+		data := account.Data.GetBinary()
+		if len(data) < tickMinSize ||
+			!solana.PublicKeyFromBytes(data[tickPoolOffset:tickPoolOffset+32]).Equals(pool.Address) ||
+			int32(binary.LittleEndian.Uint32(data[tickIndexOffset:])) != indexes[i] {
+			return nil, fmt.Errorf("неожиданный формат тика %d пула %s", indexes[i], pool.Address)
+		}
+
+		ticks[indexes[i]] = Tick{
+			Index:           indexes[i],
+			Sign:            data[tickSignOffset] != 0,
+			LiquidityChange: readUint128(data[tickLiquidityChangeOffset : tickLiquidityChangeOffset+16]),
+		}
+	}
+
+	return ticks, nil
+}
@@ -0,0 +1,34 @@
+package invariant
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// sqrtPriceLimit scales sqrtPrice by sqrt(1 - slippage) when selling x, which
+// pushes the price down, or by sqrt(1 + slippage) when selling y.
+func sqrtPriceLimit(sqrtPrice *big.Int, xToY bool, slippageBps int) *big.Int {
+	bps := int64(10000 + slippageBps)
+	if xToY {
+		bps = int64(10000 - slippageBps)
+	}
+
+	factor := new(big.Float).SetPrec(256).Quo(big.NewFloat(float64(bps)), big.NewFloat(10000))
+	factor.Sqrt(factor)
+
+	limit, _ := new(big.Float).SetPrec(256).Mul(new(big.Float).SetInt(sqrtPrice), factor).Int(nil)
+
+	return limit
+}
+
+func readUint128(b []byte) *big.Int {
+	lo := new(big.Int).SetUint64(binary.LittleEndian.Uint64(b[0:8]))
+	hi := new(big.Int).SetUint64(binary.LittleEndian.Uint64(b[8:16]))
+	return hi.Lsh(hi, 64).Or(hi, lo)
+}
+
+func writeUint128(b []byte, v *big.Int) {
+	mask := new(big.Int).SetUint64(^uint64(0))
+	binary.LittleEndian.PutUint64(b[0:8], new(big.Int).And(v, mask).Uint64())
+	binary.LittleEndian.PutUint64(b[8:16], new(big.Int).Rsh(v, 64).Uint64())
+}
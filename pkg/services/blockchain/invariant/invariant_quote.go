@@ -0,0 +1,43 @@
+package invariant
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/internal/token"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/fees"
+)
+
+// QuoteUsdcToEth computes the output from the pool state the swap would be
+// built against and prices the transaction as the fee policy would send it.
+func (m *Module) QuoteUsdcToEth(ctx context.Context, exec interfaces.ExecutionContext, amount uint64) (interfaces.Quote, error) {
+	swap, err := BuildSwap(ctx, exec.RpcClient, token.SwapInstructions{
+		Payer:       exec.EclipseAccount.PrivateKey,
+		FirstToken:  configs.MustToken("USDC").Address,
+		SecondToken: configs.MustToken("ETH").Address,
+		Amount:      amount,
+		IsETH:       false,
+	}, exec.Config.Slippage.Invariant, false)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	instructions, err := exec.FeePolicy().Apply(ctx, swap.Instructions, exec.EclipseAccount.PublicKey, swap.Tables)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	signatures := 1
+	if swap.TempAccount != nil {
+		// the temporary account signs the transaction too, its rent is
+		// refunded when it is closed at the end
+		signatures++
+	}
+
+	fee, err := fees.RouteFee(ctx, exec.RpcClient, instructions, signatures)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	return interfaces.Quote{AmountOut: swap.AmountOut, Fee: fee}, nil
+}
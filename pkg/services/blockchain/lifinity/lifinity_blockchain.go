@@ -2,429 +2,189 @@
 
 import (
 	"context"
-	"eclipse/constants"
-	"eclipse/internal/base"
-	"eclipse/internal/logger"
+	"eclipse/configs"
 	"eclipse/internal/token"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
 	"encoding/binary"
 	"fmt"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
-	"math"
-	"math/rand"
-	"time"
 )
 
 var (
 	TOKEN_2022_PROGRAM_ID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
 	SYSTEM_PROGRAM_ID     = solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
-	ATA_PROGRAM_ID        = solana.MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
-	COMPUTE_BUDGET_ID     = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
 	LIFINITY_PROGRAM_ID   = solana.MustPublicKeyFromBase58("4UsSbJQZJTfZDFrgvcPBRCSg5BbcQE6dobnriCafzj12")
 	MINT_DATA             = solana.MustPublicKeyFromBase58("9pan9bMn5HatX4EJdBwg9VgCa7Uz5HL8N1m5D3NdXejP")
-	MIN_COMPUTE_UNITS     = 131000
-	MAX_COMPUTE_UNITS     = 138000
 )
 
-func createSwapConfig(baseConfig base.SwapConfig, swapData []byte, needsWSol bool) base.SwapConfig {
-	config := baseConfig
-	config.SwapData = swapData
-	config.NeedsWSol = needsWSol
-	return config
-}
-
-func createCloseAccountInstruction(params token.SwapInstructions) (solana.Instruction, error) {
-	sourceATA, _, err := token.FindAssociatedTokenAddress2022(params.Payer.PublicKey(), MINT_DATA)
-	if err != nil {
-		return nil, err
+// poolMint maps a registry mint to the mint Lifinity pools hold. Native ETH
+// is traded as the Token-2022 wrapped mint.
+func poolMint(mint solana.PublicKey) solana.PublicKey {
+	if mint.Equals(configs.MustToken("ETH").Address) {
+		return MINT_DATA
 	}
-
-	return solana.NewInstruction(
-		TOKEN_2022_PROGRAM_ID,
-		solana.AccountMetaSlice{
-			solana.NewAccountMeta(sourceATA, true, false),
-			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-		},
-		[]byte{9},
-	), nil
+	return mint
 }
 
-func createWSolInstructions(params token.SwapInstructions, sourceATA solana.PublicKey) []solana.Instruction {
-	transferAmount := uint64(100000)
+func createWrapInstructions(params token.SwapInstructions, wrappedATA, program solana.PublicKey) []solana.Instruction {
 	transferData := make([]byte, 12)
 	transferData[0] = 2
-	binary.LittleEndian.PutUint64(transferData[4:], transferAmount)
+	binary.LittleEndian.PutUint64(transferData[4:], params.Amount)
 
 	return []solana.Instruction{
 		solana.NewInstruction(
 			SYSTEM_PROGRAM_ID,
 			solana.AccountMetaSlice{
 				solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-				solana.NewAccountMeta(sourceATA, false, true),
+				solana.NewAccountMeta(wrappedATA, true, false),
 			},
 			transferData,
 		),
 		solana.NewInstruction(
-			TOKEN_2022_PROGRAM_ID,
+			program,
 			solana.AccountMetaSlice{
-				solana.NewAccountMeta(sourceATA, false, true),
-				solana.NewAccountMeta(params.FirstToken, false, false),
-				solana.NewAccountMeta(params.Payer.PublicKey(), false, false),
-			},
-			[]byte{0x11},
-		),
-	}
-}
-
-func calculateRequiredComputeUnits() uint32 {
-	rand.Seed(time.Now().UnixNano())
-	units := rand.Intn(MAX_COMPUTE_UNITS-MIN_COMPUTE_UNITS+1) + MIN_COMPUTE_UNITS
-
-	return uint32(units)
-}
-
-func createCommonInstructions(ctx context.Context, params token.SwapInstructions, config base.SwapConfig) ([]solana.Instruction, error) {
-	instructions := make([]solana.Instruction, 0)
-
-	computeUnits := calculateRequiredComputeUnits()
-	computeUnitLimitIx := solana.NewInstruction(
-		COMPUTE_BUDGET_ID,
-		solana.AccountMetaSlice{},
-		[]byte{
-			0x02,
-			byte(computeUnits & 0xff),
-			byte((computeUnits >> 8) & 0xff),
-			byte((computeUnits >> 16) & 0xff),
-			byte((computeUnits >> 24) & 0xff),
-		},
-	)
-
-	instructions = append(instructions, computeUnitLimitIx)
-
-	sourceATA, _, err := token.FindAssociatedTokenAddress2022(params.Payer.PublicKey(), MINT_DATA)
-
-	destinationATAFirst, _, err := token.FindAssociatedTokenAddress2022(params.Payer.PublicKey(), params.FirstToken)
-	if err != nil {
-		return nil, fmt.Errorf("error finding destination ATA: %v", err)
-	}
-
-	destinationATASecond, _, err := token.FindAssociatedTokenAddress2022(params.Payer.PublicKey(), params.SecondToken)
-	if err != nil {
-		return nil, fmt.Errorf("error finding destination ATA: %v", err)
-	}
-
-	var ataInstruction *solana.GenericInstruction = nil
-	if params.IsETH {
-		ataInstruction = solana.NewInstruction(
-			ATA_PROGRAM_ID,
-			solana.AccountMetaSlice{
-				solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-				solana.NewAccountMeta(sourceATA, true, false),
-				solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-				solana.NewAccountMeta(MINT_DATA, true, false),
-				solana.NewAccountMeta(SYSTEM_PROGRAM_ID, false, false),
-				solana.NewAccountMeta(TOKEN_2022_PROGRAM_ID, false, false),
-			},
-			[]byte{},
-		)
-
-		instructions = append(instructions, ataInstruction)
-
-		transferData := make([]byte, 12)
-		transferData[0] = 2
-		binary.LittleEndian.PutUint64(transferData[4:], params.Amount)
-
-		systemTransferIx := solana.NewInstruction(
-			SYSTEM_PROGRAM_ID,
-			solana.AccountMetaSlice{
-				solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-				solana.NewAccountMeta(sourceATA, true, false),
-			},
-			transferData,
-		)
-		instructions = append(instructions, systemTransferIx)
-
-		syncNativeIx := solana.NewInstruction(
-			TOKEN_2022_PROGRAM_ID,
-			solana.AccountMetaSlice{
-				solana.NewAccountMeta(sourceATA, true, false),
+				solana.NewAccountMeta(wrappedATA, true, false),
 			},
 			[]byte{
 				0x11,
 			},
-		)
-		instructions = append(instructions, syncNativeIx)
-	} else {
-		ataInstruction = solana.NewInstruction(
-			ATA_PROGRAM_ID,
-			solana.AccountMetaSlice{
-				solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-				solana.NewAccountMeta(sourceATA, true, false),
-				solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-				solana.NewAccountMeta(MINT_DATA, true, false),
-				solana.NewAccountMeta(SYSTEM_PROGRAM_ID, false, false),
-				solana.NewAccountMeta(TOKEN_2022_PROGRAM_ID, false, false),
-			},
-			[]byte{1},
-		)
-
-		instructions = append(instructions, ataInstruction)
+		),
 	}
+}
 
-	//if config.NeedsWSol {
-	//	wsolInstructions := createWSolInstructions(params, sourceATA)
-	//	instructions = append(instructions, wsolInstructions...)
-	//}
-
-	var swapAccounts []*solana.AccountMeta = nil
-	if params.IsETH {
-		swapAccounts = solana.AccountMetaSlice{
-			solana.NewAccountMeta(config.PoolAddress, false, false),
-			solana.NewAccountMeta(config.StateAddress, true, false),
+func createCloseAccountInstruction(params token.SwapInstructions, wrappedATA, program solana.PublicKey) solana.Instruction {
+	return solana.NewInstruction(
+		program,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(wrappedATA, true, false),
 			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-			solana.NewAccountMeta(sourceATA, true, false),
-			solana.NewAccountMeta(destinationATASecond, true, false),
-			solana.NewAccountMeta(config.VaultA, true, false),
-			solana.NewAccountMeta(config.VaultB, true, false),
-			solana.NewAccountMeta(MINT_DATA, true, false),
-			solana.NewAccountMeta(params.SecondToken, true, false),
-			solana.NewAccountMeta(config.FeeAccount, true, false),
-			solana.NewAccountMeta(config.FeeState, true, false),
-			solana.NewAccountMeta(TOKEN_2022_PROGRAM_ID, false, false),
-			solana.NewAccountMeta(config.PoolAuthority, false, false),
-			solana.NewAccountMeta(config.PoolAuthority, false, false),
-			solana.NewAccountMeta(config.OracleAddress, false, false),
-		}
-	} else {
-		swapAccounts = solana.AccountMetaSlice{
-			solana.NewAccountMeta(config.PoolAddress, false, false),
-			solana.NewAccountMeta(config.StateAddress, true, false),
 			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
-			solana.NewAccountMeta(destinationATAFirst, true, false),
-			solana.NewAccountMeta(sourceATA, true, false),
-			solana.NewAccountMeta(config.VaultB, true, false),
-			solana.NewAccountMeta(config.VaultA, true, false),
-			solana.NewAccountMeta(params.FirstToken, true, false),
-			solana.NewAccountMeta(MINT_DATA, true, false),
-			solana.NewAccountMeta(config.FeeAccount, true, false),
-			solana.NewAccountMeta(config.FeeState, true, false),
-			solana.NewAccountMeta(TOKEN_2022_PROGRAM_ID, false, false),
-			solana.NewAccountMeta(config.PoolAuthority, false, false),
-			solana.NewAccountMeta(config.PoolAuthority, false, false),
-			solana.NewAccountMeta(config.OracleAddress, false, false),
-		}
-	}
-
-	swapIx := solana.NewInstruction(config.ProgramID, swapAccounts, config.SwapData)
-	instructions = append(instructions, swapIx)
-
-	return instructions, nil
+		},
+		[]byte{9},
+	)
 }
 
-func CreateFullSwapInstructionsFromEthToUsdc(ctx context.Context, params token.SwapInstructions) ([]solana.Instruction, error) {
+func createSwapInstruction(params token.SwapInstructions, pool *Pool, inputMint, outputMint, sourceATA, destinationATA solana.PublicKey) solana.Instruction {
 	swapData := make([]byte, 24)
 	copy(swapData[0:8], []byte{0xf8, 0xc6, 0x9e, 0x91, 0xe1, 0x75, 0x87, 0xc8})
 	binary.LittleEndian.PutUint64(swapData[8:16], params.Amount)
 	binary.LittleEndian.PutUint64(swapData[16:24], 0)
 
-	config := createSwapConfig(ETH_USDC_POOL, swapData, false)
+	swapSource, swapDestination := pool.Vaults(inputMint)
 
-	instructions, err := createCommonInstructions(ctx, params, config)
+	return solana.NewInstruction(
+		LIFINITY_PROGRAM_ID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(pool.Authority, false, false),
+			solana.NewAccountMeta(pool.Address, true, false),
+			solana.NewAccountMeta(params.Payer.PublicKey(), true, true),
+			solana.NewAccountMeta(sourceATA, true, false),
+			solana.NewAccountMeta(destinationATA, true, false),
+			solana.NewAccountMeta(swapSource, true, false),
+			solana.NewAccountMeta(swapDestination, true, false),
+			solana.NewAccountMeta(inputMint, true, false),
+			solana.NewAccountMeta(outputMint, true, false),
+			solana.NewAccountMeta(pool.PoolMint, true, false),
+			solana.NewAccountMeta(pool.FeeAccount, true, false),
+			solana.NewAccountMeta(pool.TokenProgram, false, false),
+			solana.NewAccountMeta(pool.OracleMain, false, false),
+			solana.NewAccountMeta(pool.OracleSub, false, false),
+			solana.NewAccountMeta(pool.OraclePc, false, false),
+		},
+		swapData,
+	)
+}
+
+// CreateSwapInstructions builds a swap of params.FirstToken into
+// params.SecondToken through the Lifinity pool of that pair, wrapping and
+// unwrapping native ETH when it is on either side.
+func CreateSwapInstructions(ctx context.Context, client *rpc.Client, params token.SwapInstructions) ([]solana.Instruction, error) {
+	inputMint := poolMint(params.FirstToken)
+	outputMint := poolMint(params.SecondToken)
+	payer := params.Payer.PublicKey()
+
+	pool, err := FindPool(ctx, client, inputMint, outputMint)
 	if err != nil {
 		return nil, err
 	}
 
-	closeAccountIx, err := createCloseAccountInstruction(params)
+	sourceATA, sourceIx, err := token.CreateATAIdempotent(ctx, client, payer, payer, inputMint)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error resolving source ATA: %v", err)
 	}
-	instructions = append(instructions, closeAccountIx)
-
-	return instructions, nil
-}
 
-func CreateFullSwapInstructionsFromUsdcToEth(ctx context.Context, params token.SwapInstructions) ([]solana.Instruction, error) {
-	swapData := make([]byte, 24)
-	copy(swapData[0:8], []byte{0xf8, 0xc6, 0x9e, 0x91, 0xe1, 0x75, 0x87, 0xc8})
-	binary.LittleEndian.PutUint64(swapData[8:16], params.Amount)
-	binary.LittleEndian.PutUint64(swapData[16:24], 0)
-
-	config := createSwapConfig(ETH_USDC_POOL, swapData, false)
-
-	instructions, err := createCommonInstructions(ctx, params, config)
+	destinationATA, destinationIx, err := token.CreateATAIdempotent(ctx, client, payer, payer, outputMint)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error resolving destination ATA: %v", err)
 	}
 
-	closeAccountIx, err := createCloseAccountInstruction(params)
+	_, wrappedProgram, err := token.ResolveATA(ctx, client, payer, MINT_DATA)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error resolving wrapped ETH account: %v", err)
 	}
-	instructions = append(instructions, closeAccountIx)
-
-	return instructions, nil
-}
-func CreateFullSwapInstructionsFromUsdcToSol(ctx context.Context, params token.SwapInstructions) ([]solana.Instruction, error) {
-	swapData := make([]byte, 24)
-	copy(swapData[0:8], []byte{0xf8, 0xc6, 0x9e, 0x91, 0xe1, 0x75, 0x87, 0xc8})
-	binary.LittleEndian.PutUint64(swapData[8:16], params.Amount)
-	binary.LittleEndian.PutUint64(swapData[16:24], 0)
-
-	config := createSwapConfig(SOL_USDC_POOL, swapData, false)
 
-	return createCommonInstructions(ctx, params, config)
-}
+	instructions := []solana.Instruction{sourceIx, destinationIx}
 
-func CreateFullSwapInstructionsFromSolToUsdc(ctx context.Context, params token.SwapInstructions) ([]solana.Instruction, error) {
-	swapData := make([]byte, 24)
-	copy(swapData[0:8], []byte{0xf8, 0xc6, 0x9e, 0x91, 0xe1, 0x75, 0x87, 0xc8})
-	binary.LittleEndian.PutUint64(swapData[8:16], params.Amount)
-	binary.LittleEndian.PutUint64(swapData[16:24], 0)
-
-	config := createSwapConfig(SOL_USDC_POOL, swapData, false)
+	if inputMint.Equals(MINT_DATA) {
+		instructions = append(instructions, createWrapInstructions(params, sourceATA, wrappedProgram)...)
+	}
 
-	return createCommonInstructions(ctx, params, config)
-}
+	instructions = append(instructions, createSwapInstruction(params, pool, inputMint, outputMint, sourceATA, destinationATA))
 
-func DetermineSwapDirection(firstToken, secondToken solana.PublicKey) (string, error) {
 	switch {
-	case firstToken.Equals(USDC) && secondToken.Equals(WETH):
-		return "USDC_TO_ETH", nil
-	case firstToken.Equals(WETH) && secondToken.Equals(USDC):
-		return "ETH_TO_USDC", nil
-	case firstToken.Equals(USDC) && secondToken.Equals(WSOL):
-		return "USDC_TO_SOL", nil
-	case firstToken.Equals(WSOL) && secondToken.Equals(USDC):
-		return "SOL_TO_USDC", nil
-	default:
-		return "", fmt.Errorf("unsupported token pair: %s -> %s", firstToken, secondToken)
+	case inputMint.Equals(MINT_DATA):
+		instructions = append(instructions, createCloseAccountInstruction(params, sourceATA, wrappedProgram))
+	case outputMint.Equals(MINT_DATA):
+		instructions = append(instructions, createCloseAccountInstruction(params, destinationATA, wrappedProgram))
 	}
-}
-
-func ConvertToRawAmount(amount float64, decimals int) uint64 {
-	multiplier := math.Pow(10, float64(decimals))
-	rawAmount := amount * multiplier
-	return uint64(rawAmount)
-}
 
-func GetTokenDecimals(token solana.PublicKey) int {
-	info, exists := tokenInfo[token.String()]
-	if !exists {
-		return 6
-	}
-	return int(info.decimals)
+	return instructions, nil
 }
 
-func ExecuteSwap(ctx context.Context, client *rpc.Client, params SwapParams) (solana.Signature, error) {
-	swapDirection, err := DetermineSwapDirection(params.FromToken, params.ToToken)
-	if err != nil {
-		return solana.Signature{}, err
-	}
-
-	var instructions []solana.Instruction
+func ExecuteSwap(ctx context.Context, client *rpc.Client, params SwapParams, tracker *sender.Tracker, policy *fees.Policy) (solana.Signature, error) {
 	swapParams := token.SwapInstructions{
 		Payer:       params.Wallet,
 		FirstToken:  params.FromToken,
 		SecondToken: params.ToToken,
-		Amount:      ConvertToRawAmount(params.Amount, GetTokenDecimals(params.FromToken)),
+		Amount:      params.Amount,
 		IsETH:       params.IsETH,
 	}
 
-	switch swapDirection {
-	case "USDC_TO_ETH":
-		instructions, err = CreateFullSwapInstructionsFromUsdcToEth(ctx, swapParams)
-	case "ETH_TO_USDC":
-		instructions, err = CreateFullSwapInstructionsFromEthToUsdc(ctx, swapParams)
-	case "USDC_TO_SOL":
-		instructions, err = CreateFullSwapInstructionsFromUsdcToSol(ctx, swapParams)
-	case "SOL_TO_USDC":
-		instructions, err = CreateFullSwapInstructionsFromSolToUsdc(ctx, swapParams)
-	default:
-		return solana.Signature{}, fmt.Errorf("unsupported swap direction: %s", swapDirection)
-	}
-
+	instructions, err := CreateSwapInstructions(ctx, client, swapParams)
 	if err != nil {
 		return solana.Signature{}, fmt.Errorf("error creating swap instructions: %v", err)
 	}
 
-	return ExecuteTransaction(ctx, client, instructions, params.Wallet)
-}
-
-func ExecuteTransaction(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, feePayer solana.PrivateKey) (solana.Signature, error) {
-	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error getting latest blockhash: %v", err)
-	}
-
-	tx, err := solana.NewTransaction(
-		instructions,
-		recent.Value.Blockhash,
-		solana.TransactionPayer(feePayer.PublicKey()),
-	)
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error creating transaction: %v", err)
+	if err := applyMinimumAmountOut(ctx, client, instructions, params.Wallet.PublicKey(), params.SlippageBps); err != nil {
+		forgetPool(poolMint(params.FromToken), poolMint(params.ToToken))
+		return solana.Signature{}, err
 	}
 
-	tx.Message.SetVersion(0)
-
-	for i := range tx.Message.AccountKeys {
-		if tx.Message.AccountKeys[i].Equals(feePayer.PublicKey()) {
-			tx.Message.Header.NumRequiredSignatures = 1
-			tx.Message.Header.NumReadonlySignedAccounts = 0
-			break
-		}
-	}
+	return ExecuteTransaction(ctx, client, instructions, params.Wallet, tracker, policy)
+}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(feePayer.PublicKey()) {
-			return &feePayer
-		}
-		return nil
-	})
+func ExecuteTransaction(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, feePayer solana.PrivateKey, tracker *sender.Tracker, policy *fees.Policy) (solana.Signature, error) {
+	instructions, err := policy.Apply(ctx, instructions, feePayer.PublicKey(), nil)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error signing transaction: %v", err)
+		return solana.Signature{}, fmt.Errorf("error applying priority fee: %v", err)
 	}
 
-	retrie := uint(5)
-	sig, err := client.SendTransactionWithOpts(ctx, tx,
-		rpc.TransactionOpts{
-			SkipPreflight:       false,
-			PreflightCommitment: rpc.CommitmentFinalized,
-			MaxRetries:          &retrie,
-			MinContextSlot:      nil,
-		},
-	)
+	tx, lastValidBlockHeight, err := sender.BuildTransaction(ctx, client, instructions, feePayer, nil)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error sending transaction: %v", err)
+		return solana.Signature{}, err
 	}
 
-	time.Sleep(time.Second * 1)
-
-	maxAttempts := 15
-	for i := 0; i < maxAttempts; i++ {
-		response, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
-			Commitment: rpc.CommitmentConfirmed,
-		})
-		if err != nil {
-			if i < maxAttempts-1 {
-				logger.Info("Attempt %d: Waiting for confirmation... (%v)", i+1, err)
-				time.Sleep(time.Second * 3)
-				continue
-			}
-			return sig, fmt.Errorf("transaction failed: %v", err)
-		}
+	opts := sender.DefaultOptions()
+	opts.PreflightCommitment = rpc.CommitmentFinalized
+	opts.LastValidBlockHeight = lastValidBlockHeight
+	opts.Tracker = tracker
 
-		if response != nil {
-			if response.Meta.Err != nil {
-				return sig, fmt.Errorf("transaction failed with error: %v", response.Meta.Err)
-			}
-			logger.Success("Transaction sent succesfully: %s%s", constants.EclipseScan, sig)
-			return sig, nil
-		}
-
-		time.Sleep(time.Second * 3)
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		return result.Signature, fmt.Errorf("error sending transaction: %w", err)
 	}
 
-	return sig, fmt.Errorf("transaction not confirmed after %d attempts", maxAttempts)
+	return result.Signature, nil
 }
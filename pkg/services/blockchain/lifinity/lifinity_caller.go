@@ -2,64 +2,62 @@
 
 import (
 	"context"
-	"database/sql"
 	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/base"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
 	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/randomizer"
-	"eclipse/pkg/services/telegram"
 	"eclipse/utils/balance"
 	"fmt"
-	"net/http"
+	"math"
 	"strconv"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 )
 
-var (
-	USDC = solana.MustPublicKeyFromBase58("AKEWE7Bgh87GPp171b4cJPSSZfmZwQ3KaqYqXoKLNAEE")
-	WETH = solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
-	WSOL = solana.MustPublicKeyFromBase58("BeRUj3h7BqkbdfFU7FBNYbodgf8GCHodzKvF9aVjNNfL")
-)
-
-var tokenInfo = map[string]struct {
-	decimals int8
-	symbol   string
-}{
-	"AKEWE7Bgh87GPp171b4cJPSSZfmZwQ3KaqYqXoKLNAEE": {6, "USDC"},
-	"So11111111111111111111111111111111111111112":  {9, "ETH"},
-	"BeRUj3h7BqkbdfFU7FBNYbodgf8GCHodzKvF9aVjNNfL": {9, "SOL"},
-}
-
+// SwapParams is a swap of Amount base units of FromToken into ToToken.
 type SwapParams struct {
-	Amount    float64
-	FromToken solana.PublicKey
-	ToToken   solana.PublicKey
-	Wallet    solana.PrivateKey
-	IsETH     bool
+	Amount      uint64
+	FromToken   solana.PublicKey
+	ToToken     solana.PublicKey
+	Wallet      solana.PrivateKey
+	IsETH       bool
+	SlippageBps int
 }
 
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	httpClient http.Client,
-	client *rpc.Client,
-	cfg configs.AppConfig,
-	acc *model.EclipseAccount,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Lifinity",
+		ConfigKey: "lifinity",
+		IsSwap:    true,
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Lifinity Swap")
 
+	cfg := exec.Config
+	acc := exec.EclipseAccount
+	client := exec.RpcClient
+	notifier := exec.Notifier
+	db := exec.DB
+	maxAttempts := exec.MaxAttempts()
+	tracker := sender.NewTracker(client)
+	policy := exec.FeePolicy()
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		if cfg.Modules.Mode == "eth" {
 			amountDecimals, err := balance.GetUSDCBalance(ctx, client, acc.PublicKey)
 			if err != nil {
@@ -73,20 +71,27 @@ func (m *Module) Execute(
 			logger.Info("Пытаюсь выполнить свап всего баланса USDC -> ETH")
 
 			swapParams := SwapParams{
-				FromToken: USDC,
-				ToToken:   WETH,
-				Wallet:    acc.PrivateKey,
-				IsETH:     false,
+				Amount:      amountDecimals,
+				FromToken:   configs.MustToken("USDC").Address,
+				ToToken:     configs.MustToken("ETH").Address,
+				Wallet:      acc.PrivateKey,
+				IsETH:       false,
+				SlippageBps: cfg.Slippage.Lifinity,
 			}
 
-			sig, err := ExecuteSwap(ctx, client, swapParams)
+			sig, err := ExecuteSwap(ctx, client, swapParams, tracker, policy)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			}
 
-			amount := float64(amountDecimals) / 1_000_000
+			amount := float64(amountDecimals) / math.Pow10(int(configs.MustToken("USDC").Decimals))
 
 			if db != nil && cfg.Database.Enabled {
 				err = database.AddModule(
@@ -110,7 +115,10 @@ func (m *Module) Execute(
 			)
 			return true, nil
 		} else {
-			firstPair, secondPair, tokenType, err := base.GetRandomTokenPair(cfg.Invariant.Tokens)
+			firstPair, secondPair, tokenType, err := base.GetRandomPoolPair(cfg.Lifinity.Tokens, func(first, second configs.Token) error {
+				_, err := FindPool(ctx, client, poolMint(first.Address), poolMint(second.Address))
+				return err
+			})
 			if err != nil {
 				return false, fmt.Errorf("error getting token pair: %v", err)
 			}
@@ -121,26 +129,26 @@ func (m *Module) Execute(
 			switch tokenType {
 			case "ETH":
 				value, valueStr = randomizer.GetRandomValueWithPrecision(
-					cfg.Invariant.Native.ETH.MinValue,
-					cfg.Invariant.Native.ETH.MaxValue,
-					cfg.Invariant.Native.ETH.MinPrecision,
-					cfg.Invariant.Native.ETH.MaxPrecision,
+					cfg.Lifinity.Native.ETH.MinValue,
+					cfg.Lifinity.Native.ETH.MaxValue,
+					cfg.Lifinity.Native.ETH.MinPrecision,
+					cfg.Lifinity.Native.ETH.MaxPrecision,
 					float64(firstPair.Decimals),
 				)
 			case "SOL":
 				value, valueStr = randomizer.GetRandomValueWithPrecision(
-					cfg.Invariant.Native.SOL.MinValue,
-					cfg.Invariant.Native.SOL.MaxValue,
-					cfg.Invariant.Native.SOL.MinPrecision,
-					cfg.Invariant.Native.SOL.MaxPrecision,
+					cfg.Lifinity.Native.SOL.MinValue,
+					cfg.Lifinity.Native.SOL.MaxValue,
+					cfg.Lifinity.Native.SOL.MinPrecision,
+					cfg.Lifinity.Native.SOL.MaxPrecision,
 					float64(firstPair.Decimals),
 				)
 			case "stable":
 				value, valueStr = randomizer.GetRandomValueWithPrecision(
-					cfg.Invariant.Stable.MinValue,
-					cfg.Invariant.Stable.MaxValue,
-					cfg.Invariant.Stable.MinPrecision,
-					cfg.Invariant.Stable.MaxPrecision,
+					cfg.Lifinity.Stable.MinValue,
+					cfg.Lifinity.Stable.MaxValue,
+					cfg.Lifinity.Stable.MinPrecision,
+					cfg.Lifinity.Stable.MaxPrecision,
 					float64(firstPair.Decimals),
 				)
 			default:
@@ -152,7 +160,7 @@ func (m *Module) Execute(
 				return false, fmt.Errorf("error parsing value string: %v", err)
 			}
 
-			isETH := firstPair.Address.String() == WETH.String()
+			isETH := firstPair.Address.String() == configs.MustToken("ETH").Address.String()
 
 			params := token.SwapInstructions{
 				Payer:         acc.PrivateKey,
@@ -173,17 +181,23 @@ func (m *Module) Execute(
 			}
 
 			swapParams := SwapParams{
-				Amount:    value,
-				FromToken: firstPair.Address,
-				ToToken:   secondPair.Address,
-				Wallet:    acc.PrivateKey,
-				IsETH:     isETH,
+				Amount:      amountDecimals,
+				FromToken:   firstPair.Address,
+				ToToken:     secondPair.Address,
+				Wallet:      acc.PrivateKey,
+				IsETH:       isETH,
+				SlippageBps: cfg.Slippage.Lifinity,
 			}
 
-			sig, err := ExecuteSwap(ctx, client, swapParams)
+			sig, err := ExecuteSwap(ctx, client, swapParams, tracker, policy)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			} else {
 				formatString := fmt.Sprintf("%%.%df", firstPair.Decimals)
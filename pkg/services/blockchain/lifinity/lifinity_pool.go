@@ -0,0 +1,226 @@
+package lifinity
+
+import (
+	"bytes"
+	"context"
+	"eclipse/internal/logger"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Offsets of the fields we need in a Lifinity v2 Amm account, counted from
+// the start of the data including the 8 byte anchor discriminator.
+const (
+	ammIsInitializedOffset = 120
+	ammFreezeTradeOffset   = 122
+	ammTokenProgramOffset  = 126
+	ammTokenAAccountOffset = 158
+	ammTokenBAccountOffset = 190
+	ammPoolMintOffset      = 222
+	ammTokenAMintOffset    = 254
+	ammTokenBMintOffset    = 286
+	ammFeeAccountOffset    = 318
+	ammOracleMainOffset    = 350
+	ammOracleSubOffset     = 382
+	ammOraclePcOffset      = 414
+	ammDecodedLength       = 446
+)
+
+type Pool struct {
+	Address      solana.PublicKey
+	Authority    solana.PublicKey
+	TokenProgram solana.PublicKey
+	MintA        solana.PublicKey
+	MintB        solana.PublicKey
+	VaultA       solana.PublicKey
+	VaultB       solana.PublicKey
+	PoolMint     solana.PublicKey
+	FeeAccount   solana.PublicKey
+	OracleMain   solana.PublicKey
+	OracleSub    solana.PublicKey
+	OraclePc     solana.PublicKey
+}
+
+// Vaults returns the pool vaults the swap takes inputMint from and pays the
+// other token out of.
+func (p *Pool) Vaults(inputMint solana.PublicKey) (solana.PublicKey, solana.PublicKey) {
+	if inputMint.Equals(p.MintA) {
+		return p.VaultA, p.VaultB
+	}
+	return p.VaultB, p.VaultA
+}
+
+type poolKey [2]solana.PublicKey
+
+func newPoolKey(a, b solana.PublicKey) poolKey {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return poolKey{a, b}
+}
+
+// Pools are looked up once per run and shared by all accounts. A nil entry
+// means the pair has no pool.
+var (
+	pools   = make(map[poolKey]*Pool)
+	poolsMu sync.Mutex
+)
+
+// FindPool returns the Lifinity pool trading mintA against mintB in either
+// order.
+func FindPool(ctx context.Context, client *rpc.Client, mintA, mintB solana.PublicKey) (*Pool, error) {
+	key := newPoolKey(mintA, mintB)
+
+	poolsMu.Lock()
+	pool, cached := pools[key]
+	poolsMu.Unlock()
+	if cached {
+		if pool == nil {
+			return nil, fmt.Errorf("пул Lifinity для пары %s/%s не найден", mintA, mintB)
+		}
+		return pool, nil
+	}
+
+	pool, err := discoverPool(ctx, client, mintA, mintB)
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		pool, err = discoverPool(ctx, client, mintB, mintA)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	poolsMu.Lock()
+	pools[key] = pool
+	poolsMu.Unlock()
+
+	if pool == nil {
+		return nil, fmt.Errorf("пул Lifinity для пары %s/%s не найден", mintA, mintB)
+	}
+
+	logger.Info("Найден пул Lifinity %s для пары %s/%s", pool.Address, mintA, mintB)
+
+	return pool, nil
+}
+
+// forgetPool drops the cached pool of a pair so the next lookup reads the
+// program accounts again, e.g. after the pool was migrated.
+func forgetPool(mintA, mintB solana.PublicKey) {
+	poolsMu.Lock()
+	delete(pools, newPoolKey(mintA, mintB))
+	poolsMu.Unlock()
+}
+
+func discoverPool(ctx context.Context, client *rpc.Client, mintA, mintB solana.PublicKey) (*Pool, error) {
+	accounts, err := client.GetProgramAccountsWithOpts(ctx, LIFINITY_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		DataSlice:  &rpc.DataSlice{Offset: new(uint64), Length: uint64Ptr(ammDecodedLength)},
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: ammTokenAMintOffset, Bytes: mintA.Bytes()}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: ammTokenBMintOffset, Bytes: mintB.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пулов Lifinity: %v", err)
+	}
+
+	for _, account := range accounts {
+		pool, err := decodePool(account.Pubkey, account.Account.Data.GetBinary())
+		if err != nil {
+			logger.Warning("Пропускаю пул Lifinity %s: %v", account.Pubkey, err)
+			continue
+		}
+
+		if err := verifyPool(ctx, client, pool); err != nil {
+			logger.Warning("Пропускаю пул Lifinity %s: %v", account.Pubkey, err)
+			continue
+		}
+
+		return pool, nil
+	}
+
+	return nil, nil
+}
+
+func decodePool(address solana.PublicKey, data []byte) (*Pool, error) {
+	if len(data) < ammDecodedLength {
+		return nil, fmt.Errorf("неожиданный размер аккаунта: %d", len(data))
+	}
+	if data[ammIsInitializedOffset] == 0 {
+		return nil, fmt.Errorf("пул не инициализирован")
+	}
+	if data[ammFreezeTradeOffset] != 0 {
+		return nil, fmt.Errorf("торговля в пуле заморожена")
+	}
+
+	key := func(offset int) solana.PublicKey {
+		return solana.PublicKeyFromBytes(data[offset : offset+32])
+	}
+
+	return &Pool{
+		Address:      address,
+		TokenProgram: key(ammTokenProgramOffset),
+		MintA:        key(ammTokenAMintOffset),
+		MintB:        key(ammTokenBMintOffset),
+		VaultA:       key(ammTokenAAccountOffset),
+		VaultB:       key(ammTokenBAccountOffset),
+		PoolMint:     key(ammPoolMintOffset),
+		FeeAccount:   key(ammFeeAccountOffset),
+		OracleMain:   key(ammOracleMainOffset),
+		OracleSub:    key(ammOracleSubOffset),
+		OraclePc:     key(ammOraclePcOffset),
+	}, nil
+}
+
+// verifyPool checks the decoded vaults against the token accounts on chain
+// and takes the pool authority from their owner, so a layout change fails
+// here instead of in a sent transaction.
+func verifyPool(ctx context.Context, client *rpc.Client, pool *Pool) error {
+	res, err := client.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.VaultA, pool.VaultB}, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки хранилищ пула: %v", err)
+	}
+	if len(res.Value) != 2 {
+		return fmt.Errorf("неожиданный ответ при загрузке хранилищ пула")
+	}
+
+	mints := []solana.PublicKey{pool.MintA, pool.MintB}
+	var authority solana.PublicKey
+	for i, account := range res.Value {
+		if account == nil {
+			return fmt.Errorf("хранилище пула не найдено")
+		}
+
+		data := account.Data.GetBinary()
+		if len(data) < 64 {
+			return fmt.Errorf("хранилище пула не является токен аккаунтом")
+		}
+
+		if !solana.PublicKeyFromBytes(data[0:32]).Equals(mints[i]) {
+			return fmt.Errorf("mint хранилища не совпадает с mint пула")
+		}
+
+		owner := solana.PublicKeyFromBytes(data[32:64])
+		if i > 0 && !owner.Equals(authority) {
+			return fmt.Errorf("у хранилищ пула разные владельцы")
+		}
+		authority = owner
+	}
+
+	pool.Authority = authority
+
+	return nil
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
@@ -0,0 +1,99 @@
+﻿package lifinity
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/internal/logger"
+	"eclipse/internal/token"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// quoteAmountOut simulates instructions up to the swap against the current
+// pool state and returns the swap instruction with the amount it would pay out.
+func quoteAmountOut(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, payer solana.PublicKey) (*solana.GenericInstruction, uint64, error) {
+	swapIdx := -1
+	for i, inst := range instructions {
+		if inst.ProgramID().Equals(LIFINITY_PROGRAM_ID) {
+			swapIdx = i
+			break
+		}
+	}
+	if swapIdx < 0 {
+		return nil, 0, fmt.Errorf("swap instruction not found")
+	}
+
+	swapIx, ok := instructions[swapIdx].(*solana.GenericInstruction)
+	if !ok || len(swapIx.DataBytes) < 24 || len(swapIx.AccountValues) < 5 {
+		return nil, 0, fmt.Errorf("unexpected swap instruction layout")
+	}
+	destination := swapIx.AccountValues[4].PublicKey
+
+	sim, err := sender.SimulateInstructions(ctx, client, instructions[:swapIdx+1], payer, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("не удалось получить котировку: %v", err)
+	}
+
+	amountOut := sim.TokenDelta(destination)
+	if amountOut <= 0 {
+		return nil, 0, fmt.Errorf("симуляция не показала поступления токенов на %s", destination)
+	}
+
+	return swapIx, uint64(amountOut), nil
+}
+
+// applyMinimumAmountOut writes the quoted output minus slippageBps into the
+// minimum_amount_out field of the swap instruction.
+func applyMinimumAmountOut(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, payer solana.PublicKey, slippageBps int) error {
+	swapIx, amountOut, err := quoteAmountOut(ctx, client, instructions, payer)
+	if err != nil {
+		return err
+	}
+
+	minAmountOut := amountOut * uint64(10000-slippageBps) / 10000
+	binary.LittleEndian.PutUint64(swapIx.DataBytes[16:24], minAmountOut)
+
+	logger.Info("Ожидаемый выход %d, минимальный с учетом проскальзывания %d bps: %d", amountOut, slippageBps, minAmountOut)
+
+	return nil
+}
+
+// QuoteUsdcToEth simulates the swap: Lifinity prices against its oracle and
+// curve parameters, which are not reproduced locally.
+func (m *Module) QuoteUsdcToEth(ctx context.Context, exec interfaces.ExecutionContext, amount uint64) (interfaces.Quote, error) {
+	payer := exec.EclipseAccount.PrivateKey
+
+	instructions, err := CreateSwapInstructions(ctx, exec.RpcClient, token.SwapInstructions{
+		Payer:       payer,
+		FirstToken:  configs.MustToken("USDC").Address,
+		SecondToken: configs.MustToken("ETH").Address,
+		Amount:      amount,
+		IsETH:       false,
+	})
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	_, out, err := quoteAmountOut(ctx, exec.RpcClient, instructions, payer.PublicKey())
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	instructions, err = exec.FeePolicy().Apply(ctx, instructions, payer.PublicKey(), nil)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	fee, err := fees.RouteFee(ctx, exec.RpcClient, instructions, 1)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	return interfaces.Quote{AmountOut: out, Fee: fee, Simulated: true}, nil
+}
@@ -2,28 +2,53 @@
 
 import (
 	"context"
-	"eclipse/constants"
-	"eclipse/internal/logger"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
 	"fmt"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
-	"time"
 )
 
+// maxTxSize is the size the route is asked to fit into, leaving room for the
+// compute budget instructions the fee policy prepends.
+const maxTxSize = sender.MaxTransactionSize - 64
+
 type SpecialAccount struct {
 	IsSigner   bool
 	IsWritable bool
 	IsFeePayer bool
 }
 
-func SimulateAndSendTransaction(ctx context.Context, client *rpc.Client, instructions *SwapInstructions, privateKey solana.PrivateKey) (solana.Signature, error) {
-	var solanaInstructions []solana.Instruction
+func SimulateAndSendTransaction(ctx context.Context, client *rpc.Client, instructions *SwapInstructions, privateKey solana.PrivateKey, tracker *sender.Tracker, policy *fees.Policy) (solana.Signature, error) {
+	solanaInstructions, tables, signers, err := prepareInstructions(ctx, client, instructions, privateKey.PublicKey(), policy)
+	if err != nil {
+		return solana.Signature{}, err
+	}
 
-	var secondSignerPrivateKey solana.PrivateKey
-	if len(instructions.Data.Signers) > 0 {
-		secondSignerPrivateKey = instructions.Data.Signers[0]
+	tx, lastValidBlockHeight, err := sender.BuildTransaction(ctx, client, solanaInstructions, privateKey, tables, signers...)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	opts := sender.DefaultOptions()
+	opts.PreflightCommitment = rpc.CommitmentFinalized
+	opts.LastValidBlockHeight = lastValidBlockHeight
+	opts.Tracker = tracker
+
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		return result.Signature, fmt.Errorf("transaction execution failed: %w", err)
 	}
 
+	return result.Signature, nil
+}
+
+// prepareInstructions decodes the instructions Orca returned, loads their
+// lookup tables and applies the fee policy. The extra signers are the
+// keypairs Orca generated for temporary accounts.
+func prepareInstructions(ctx context.Context, client *rpc.Client, instructions *SwapInstructions, payer solana.PublicKey, policy *fees.Policy) ([]solana.Instruction, sender.LookupTables, []solana.PrivateKey, error) {
+	var solanaInstructions []solana.Instruction
+
 	for _, inst := range instructions.Data.Instructions {
 		programID := solana.PublicKeyFromBytes(inst.ProgramID)
 		accounts := make(solana.AccountMetaSlice, len(inst.Accounts))
@@ -45,70 +70,25 @@ func SimulateAndSendTransaction(ctx context.Context, client *rpc.Client, instruc
 		solanaInstructions = append(solanaInstructions, instruction)
 	}
 
-	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error getting latest blockhash: %v", err)
+	tableAddresses := make([]solana.PublicKey, len(instructions.Data.LookupTableAccounts))
+	for i, address := range instructions.Data.LookupTableAccounts {
+		tableAddresses[i] = solana.PublicKeyFromBytes(address)
 	}
 
-	tx, err := solana.NewTransaction(
-		solanaInstructions,
-		recent.Value.Blockhash,
-		solana.TransactionPayer(privateKey.PublicKey()),
-	)
+	tables, err := sender.LoadLookupTables(ctx, client, tableAddresses)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error creating transaction: %v", err)
+		return nil, nil, nil, err
 	}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(privateKey.PublicKey()) {
-			return &privateKey
-		}
-		if len(secondSignerPrivateKey) > 0 && key.Equals(secondSignerPrivateKey.PublicKey()) {
-			return &secondSignerPrivateKey
-		}
-		return nil
-	})
+	solanaInstructions, err = policy.Apply(ctx, solanaInstructions, payer, tables)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error signing transaction: %v", err)
+		return nil, nil, nil, fmt.Errorf("error applying priority fee: %v", err)
 	}
 
-	retrie := uint(5)
-	sig, err := client.SendTransactionWithOpts(ctx, tx,
-		rpc.TransactionOpts{
-			SkipPreflight:       false,
-			PreflightCommitment: rpc.CommitmentFinalized,
-			MaxRetries:          &retrie,
-			MinContextSlot:      nil,
-		},
-	)
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("transaction execution failed: %v", err)
-	}
-
-	maxAttempts := 15
-	for i := 0; i < maxAttempts; i++ {
-		response, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
-			Commitment: rpc.CommitmentConfirmed,
-		})
-		if err != nil {
-			if i < maxAttempts-1 {
-				logger.Info("Attempt %d: Waiting for confirmation... (%v)", i+1, err)
-				time.Sleep(time.Second * 3)
-				continue
-			}
-			return sig, fmt.Errorf("transaction failed: %v", err)
-		}
-
-		if response != nil {
-			if response.Meta.Err != nil {
-				return sig, fmt.Errorf("transaction failed with error: %v", response.Meta.Err)
-			}
-			logger.Success("Transaction sent succesfully: %s%s", constants.EclipseScan, sig)
-			return sig, nil
-		}
-
-		time.Sleep(time.Second * 3)
+	var signers []solana.PrivateKey
+	if len(instructions.Data.Signers) > 0 {
+		signers = append(signers, solana.PrivateKey(instructions.Data.Signers[0]))
 	}
 
-	return sig, fmt.Errorf("transaction not confirmed after %d attempts", maxAttempts)
+	return solanaInstructions, tables, signers, nil
 }
@@ -2,42 +2,51 @@
 
 import (
 	"context"
-	"database/sql"
 	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/base"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
 	"eclipse/pkg/interfaces"
-	"eclipse/pkg/services/blockchain/lifinity"
+	"eclipse/pkg/services/blockchain/sender"
 	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/randomizer"
-	"eclipse/pkg/services/telegram"
 	"eclipse/utils/balance"
 	"fmt"
 	"strconv"
 	"time"
-
-	"github.com/gagliardetto/solana-go/rpc"
 )
 
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	rpcClient *rpc.Client,
-	cfg configs.AppConfig,
-	acc *model.EclipseAccount,
-	proxyManager interfaces.ProxyManagerInterface,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	accountIndex int,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Orca",
+		ConfigKey: "orca",
+		IsSwap:    true,
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Orca Swap")
 
+	cfg := exec.Config
+	acc := exec.EclipseAccount
+	rpcClient := exec.RpcClient
+	proxyManager := exec.ProxyManager
+	notifier := exec.Notifier
+	db := exec.DB
+	accountIndex := exec.AccountIndex
+	maxAttempts := exec.MaxAttempts()
+	tracker := sender.NewTracker(rpcClient)
+	policy := exec.FeePolicy()
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		if cfg.Modules.Mode == "eth" {
 			amountDecimals, err := balance.GetUSDCBalance(ctx, rpcClient, acc.PublicKey)
 			if err != nil {
@@ -52,8 +61,8 @@ func (m *Module) Execute(
 
 			params := token.SwapInstructions{
 				Payer:         acc.PrivateKey,
-				FirstToken:    lifinity.USDC,
-				SecondToken:   lifinity.WETH,
+				FirstToken:    configs.MustToken("USDC").Address,
+				SecondToken:   configs.MustToken("ETH").Address,
 				Amount:        amountDecimals,
 				IsETH:         false,
 				TokenSymbol:   "USDC",
@@ -67,14 +76,14 @@ func (m *Module) Execute(
 			}
 
 			quoteParams := SwapQuoteParams{
-				FromToken:            lifinity.USDC.String(),
-				ToToken:              lifinity.WETH.String(),
+				FromToken:            configs.MustToken("USDC").Address.String(),
+				ToToken:              configs.MustToken("ETH").Address.String(),
 				Amount:               strconv.FormatUint(amountDecimals, 10),
-				IsLegacy:             true,
+				IsLegacy:             false,
 				AmountIsInput:        true,
 				IncludeData:          true,
 				IncludeComputeBudget: false,
-				MaxTxSize:            1200,
+				MaxTxSize:            maxTxSize,
 				WalletAddress:        acc.PublicKey.String(),
 			}
 
@@ -86,16 +95,21 @@ func (m *Module) Execute(
 				continue
 			}
 
-			instructions, err := PrepareSwapInstructions(resp, acc.PublicKey.String(), proxy)
+			instructions, err := PrepareSwapInstructions(resp, acc.PublicKey.String(), proxy, cfg.Slippage.Orca)
 			if err != nil {
 				logger.Error("Ошибка подготовки инструкций (попытка %d/%d): %v", attempt+1, maxAttempts, err)
 				continue
 			}
 
-			sig, err := SimulateAndSendTransaction(ctx, rpcClient, instructions, acc.PrivateKey)
+			sig, err := SimulateAndSendTransaction(ctx, rpcClient, instructions, acc.PrivateKey, tracker, policy)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			}
 
@@ -165,7 +179,7 @@ func (m *Module) Execute(
 				return false, fmt.Errorf("error parsing value string: %v", err)
 			}
 
-			isETH := firstPair.Address.String() == lifinity.WETH.String()
+			isETH := firstPair.Address.String() == configs.MustToken("ETH").Address.String()
 
 			params := token.SwapInstructions{
 				Payer:         acc.PrivateKey,
@@ -181,11 +195,11 @@ func (m *Module) Execute(
 				FromToken:            firstPair.Address.String(),
 				ToToken:              secondPair.Address.String(),
 				Amount:               valueStr,
-				IsLegacy:             true,
+				IsLegacy:             false,
 				AmountIsInput:        true,
 				IncludeData:          true,
 				IncludeComputeBudget: false,
-				MaxTxSize:            1200,
+				MaxTxSize:            maxTxSize,
 				WalletAddress:        acc.PublicKey.String(),
 			}
 
@@ -204,15 +218,20 @@ func (m *Module) Execute(
 				return false, err
 			}
 
-			instructions, err := PrepareSwapInstructions(resp, acc.PublicKey.String(), proxy)
+			instructions, err := PrepareSwapInstructions(resp, acc.PublicKey.String(), proxy, cfg.Slippage.Orca)
 			if err != nil {
 				return false, err
 			}
 
-			sig, err := SimulateAndSendTransaction(ctx, rpcClient, instructions, acc.PrivateKey)
+			sig, err := SimulateAndSendTransaction(ctx, rpcClient, instructions, acc.PrivateKey, tracker, policy)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			} else {
 				formatString := fmt.Sprintf("%%.%df", firstPair.Decimals)
@@ -0,0 +1,55 @@
+package orca
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/fees"
+	"fmt"
+	"strconv"
+)
+
+// QuoteUsdcToEth prices the swap Orca would build, including the priority
+// fee of its instructions and rent of accounts they keep open.
+func (m *Module) QuoteUsdcToEth(ctx context.Context, exec interfaces.ExecutionContext, amount uint64) (interfaces.Quote, error) {
+	quoteParams := SwapQuoteParams{
+		FromToken:            configs.MustToken("USDC").Address.String(),
+		ToToken:              configs.MustToken("ETH").Address.String(),
+		Amount:               strconv.FormatUint(amount, 10),
+		IsLegacy:             false,
+		AmountIsInput:        true,
+		IncludeData:          true,
+		IncludeComputeBudget: false,
+		MaxTxSize:            maxTxSize,
+		WalletAddress:        exec.EclipseAccount.PublicKey.String(),
+	}
+
+	proxy := exec.ProxyManager.GetProxyURL(exec.AccountIndex)
+
+	resp, err := GetOrcaSwapQuote(quoteParams, proxy)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	out, err := strconv.ParseUint(resp.Data.Swap.OutputAmount, 10, 64)
+	if err != nil {
+		return interfaces.Quote{}, fmt.Errorf("invalid output amount %q: %v", resp.Data.Swap.OutputAmount, err)
+	}
+
+	swap, err := PrepareSwapInstructions(resp, quoteParams.WalletAddress, proxy, exec.Config.Slippage.Orca)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	instructions, _, signers, err := prepareInstructions(ctx, exec.RpcClient, swap, exec.EclipseAccount.PublicKey, exec.FeePolicy())
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	fee, err := fees.RouteFee(ctx, exec.RpcClient, instructions, 1+len(signers))
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	return interfaces.Quote{AmountOut: out, Fee: fee}, nil
+}
@@ -215,14 +215,14 @@ func GetOrcaSwapQuote(params SwapQuoteParams, proxy string) (*SwapResponse, erro
 	return &response, nil
 }
 
-func PrepareSwapInstructions(swapResponse *SwapResponse, walletAddress string, proxy string) (*SwapInstructions, error) {
+func PrepareSwapInstructions(swapResponse *SwapResponse, walletAddress string, proxy string, slippageBps int) (*SwapInstructions, error) {
 	config := DefaultOrcaConfig("swap-prepare-instructions")
 
 	requestBody := SwapRequestBody{
 		AmountIsInput: true,
 		Swap:          swapResponse.Data.Swap,
 		Wallet:        walletAddress,
-		Slippage:      "0.005",
+		Slippage:      strconv.FormatFloat(float64(slippageBps)/10000, 'f', -1, 64),
 	}
 
 	jsonData, err := json.Marshal(requestBody)
@@ -6,66 +6,164 @@ import (
 	"eclipse/constants"
 	"eclipse/internal/logger"
 	"eclipse/model"
+	"eclipse/pkg/services/blockchain/sender"
+	"eclipse/pkg/services/randomizer"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"math/big"
-	"strconv"
+	"strings"
+	"time"
 )
 
-func MakeRelayBridge(ctx context.Context, acc model.EvmAccount, chainData configs.Chain, txData TransactionData) (common.Hash, error) {
-	logger.Info("Произвожу вызов функции для Relay бриджа")
+const receiptTimeout = 5 * time.Minute
 
-	client, err := ethclient.Dial(chainData.RPC)
+var (
+	ErrNotConfirmed        = errors.New("transaction was sent but not confirmed")
+	ErrTransactionReverted = errors.New("transaction reverted")
+)
+
+// quoteTx is a Relay transaction with every field parsed. GasFeeCap and
+// GasTipCap are nil when the quote leaves the fees to the wallet.
+type quoteTx struct {
+	To        common.Address
+	Data      []byte
+	Value     *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+func parseQuoteTx(txData TransactionData, chainData configs.Chain) (*quoteTx, error) {
+	if txData.ChainId != 0 && txData.ChainId != chainData.ChainID {
+		return nil, fmt.Errorf("котировка Relay для сети %d, а отправка в %s (%d)", txData.ChainId, chainData.Name, chainData.ChainID)
+	}
+
+	if !common.IsHexAddress(txData.To) {
+		return nil, fmt.Errorf("некорректное поле to в котировке Relay: %q", txData.To)
+	}
+
+	var data []byte
+	if txData.Data != "" {
+		var err error
+		data, err = hexutil.Decode(txData.Data)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное поле data в котировке Relay: %v", err)
+		}
+	}
+
+	value, err := parseWei("value", txData.Value)
 	if err != nil {
-		return constants.ZeroHash, fmt.Errorf("failed to connect to the %s client: %v", chainData.Name, err)
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
 	}
 
-	nonce, err := client.PendingNonceAt(ctx, acc.Address)
+	feeCap, err := parseFee("maxFeePerGas", txData.MaxFeePerGas)
 	if err != nil {
-		return constants.ZeroHash, fmt.Errorf("failed to get nonce: %v", err)
+		return nil, err
 	}
 
-	head, err := client.HeaderByNumber(ctx, nil)
+	tipCap, err := parseFee("maxPriorityFeePerGas", txData.MaxPriorityFeePerGas)
 	if err != nil {
-		return constants.ZeroHash, fmt.Errorf("failed to get header: %v", err)
+		return nil, err
 	}
 
-	baseFee := head.BaseFee
-	if baseFee == nil {
-		return constants.ZeroHash, fmt.Errorf("base fee is nil, network might not support EIP-1559")
+	if feeCap != nil && tipCap != nil && feeCap.Cmp(tipCap) < 0 {
+		return nil, fmt.Errorf("maxFeePerGas (%s) меньше maxPriorityFeePerGas (%s) в котировке Relay", feeCap, tipCap)
 	}
 
-	suggestedGasPrice, err := client.SuggestGasPrice(ctx)
-	if err != nil {
-		return constants.ZeroHash, fmt.Errorf("failed to get suggested gas price: %v", err)
+	return &quoteTx{
+		To:        common.HexToAddress(txData.To),
+		Data:      data,
+		Value:     value,
+		GasFeeCap: feeCap,
+		GasTipCap: tipCap,
+	}, nil
+}
+
+// parseWei parses a decimal amount from a quote, nil when it is empty.
+func parseWei(field, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
 	}
 
-	maxPriorityFeePerGas := new(big.Int).Sub(suggestedGasPrice, baseFee)
-	if maxPriorityFeePerGas.Cmp(big.NewInt(0)) <= 0 {
-		maxPriorityFeePerGas = big.NewInt(1000000000)
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("некорректное поле %s в котировке Relay: %q", field, s)
 	}
 
-	maxFeePerGas := new(big.Int).Mul(big.NewInt(2), baseFee)
-	maxFeePerGas.Add(maxFeePerGas, maxPriorityFeePerGas)
+	return v, nil
+}
 
-	to := common.HexToAddress(txData.To)
+// parseFee parses a fee field. Relay sends an empty or zero fee when it
+// leaves pricing to the wallet, both yield nil.
+func parseFee(field, s string) (*big.Int, error) {
+	v, err := parseWei(field, s)
+	if err != nil || v == nil || v.Sign() == 0 {
+		return nil, err
+	}
+	return v, nil
+}
+
+// sendTransaction signs and sends a Relay transaction and waits for its
+// receipt. Chains with a base fee get an EIP-1559 transaction using the
+// quote's fee caps, the rest a legacy one priced at the quote's max fee. Gas
+// is estimated with the same fees that are sent. A non-zero hash together
+// with an error means the transaction was broadcast.
+func sendTransaction(ctx context.Context, client *ethclient.Client, acc model.EvmAccount, chainData configs.Chain, txData TransactionData) (common.Hash, error) {
+	quote, err := parseQuoteTx(txData, chainData)
+	if err != nil {
+		return constants.ZeroHash, err
+	}
 
-	feeCap, err := strconv.Atoi(txData.MaxFeePerGas)
-	tipCap, err := strconv.Atoi(txData.MaxPriorityFeePerGas)
-	value, err := strconv.Atoi(txData.Value)
+	nonce, err := client.PendingNonceAt(ctx, acc.Address)
+	if err != nil {
+		return constants.ZeroHash, fmt.Errorf("failed to get nonce: %v", err)
+	}
 
-	data := common.FromHex(txData.Data)
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return constants.ZeroHash, fmt.Errorf("failed to get header: %v", err)
+	}
 
 	msg := ethereum.CallMsg{
-		From:      acc.Address,
-		To:        &to,
-		GasFeeCap: big.NewInt(int64(feeCap)),
-		GasTipCap: big.NewInt(int64(tipCap)),
-		Value:     big.NewInt(int64(value)),
-		Data:      data,
+		From:  acc.Address,
+		To:    &quote.To,
+		Value: quote.Value,
+		Data:  quote.Data,
+	}
+
+	dynamic := head.BaseFee != nil
+	if dynamic {
+		if quote.GasTipCap == nil {
+			quote.GasTipCap, err = client.SuggestGasTipCap(ctx)
+			if err != nil {
+				return constants.ZeroHash, fmt.Errorf("failed to get suggested gas tip: %v", err)
+			}
+		}
+		if quote.GasFeeCap == nil {
+			quote.GasFeeCap = new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+			quote.GasFeeCap.Add(quote.GasFeeCap, quote.GasTipCap)
+		}
+		if quote.GasFeeCap.Cmp(quote.GasTipCap) < 0 {
+			quote.GasTipCap = new(big.Int).Set(quote.GasFeeCap)
+		}
+		msg.GasFeeCap = quote.GasFeeCap
+		msg.GasTipCap = quote.GasTipCap
+	} else {
+		if quote.GasFeeCap == nil {
+			quote.GasFeeCap, err = client.SuggestGasPrice(ctx)
+			if err != nil {
+				return constants.ZeroHash, fmt.Errorf("failed to get suggested gas price: %v", err)
+			}
+		}
+		msg.GasPrice = quote.GasFeeCap
 	}
 
 	gasLimit, err := client.EstimateGas(ctx, msg)
@@ -78,53 +176,128 @@ func MakeRelayBridge(ctx context.Context, acc model.EvmAccount, chainData config
 		return constants.ZeroHash, fmt.Errorf("failed to get balance: %v", err)
 	}
 
-	maxGasCost := new(big.Int).Mul(maxFeePerGas, big.NewInt(int64(gasLimit)))
-	totalCost := new(big.Int).Add(maxGasCost, big.NewInt(int64(value)))
+	maxGasCost := new(big.Int).Mul(quote.GasFeeCap, new(big.Int).SetUint64(gasLimit))
+	totalCost := new(big.Int).Add(maxGasCost, quote.Value)
 
 	if balance.Cmp(totalCost) < 0 {
 		return constants.ZeroHash, fmt.Errorf("insufficient balance: have %v need %v", balance, totalCost)
 	}
 
-	var gasPrice *big.Int
-	if txData.MaxFeePerGas == "0" || txData.MaxFeePerGas == "" {
-		priorityFee, err := strconv.ParseInt(txData.MaxPriorityFeePerGas, 10, 64)
-		if err != nil {
-			return constants.ZeroHash, fmt.Errorf("failed to parse MaxPriorityFeePerGas: %v", err)
-		}
-		gasPrice = big.NewInt(priorityFee)
+	chainID := big.NewInt(int64(chainData.ChainID))
+
+	var tx *types.Transaction
+	if dynamic {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: quote.GasTipCap,
+			GasFeeCap: quote.GasFeeCap,
+			Gas:       gasLimit,
+			To:        &quote.To,
+			Value:     quote.Value,
+			Data:      quote.Data,
+		})
 	} else {
-		maxFee, err := strconv.ParseInt(txData.MaxFeePerGas, 10, 64)
-		if err != nil {
-			return constants.ZeroHash, fmt.Errorf("failed to parse MaxFeePerGas: %v", err)
-		}
-		gasPrice = big.NewInt(maxFee)
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: quote.GasFeeCap,
+			Gas:      gasLimit,
+			To:       &quote.To,
+			Value:    quote.Value,
+			Data:     quote.Data,
+		})
 	}
 
-	tx := types.NewTx(&types.LegacyTx{
-		Nonce:    nonce,
-		GasPrice: gasPrice,
-		Gas:      gasLimit,
-		To:       &to,
-		Value:    big.NewInt(int64(value)),
-		Data:     data,
-	})
-
-	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(int64(chainData.ChainID))), acc.PrivateKey)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), acc.PrivateKey)
 	if err != nil {
 		return constants.ZeroHash, fmt.Errorf("failed to sign tx: %v", err)
 	}
 
+	if sender.IsDryRun(ctx) {
+		logger.Info("[dry-run] Газ: %d, изменение баланса %s: до -%s wei", gasLimit, acc.Address.Hex(), totalCost.String())
+		logger.Success("[dry-run] Симуляция успешна, транзакция не отправлена")
+		return signedTx.Hash(), nil
+	}
+
 	logger.Success("✅ Симуляция успешна! Отправляем транзакцию...")
 
+	hash := signedTx.Hash()
+
 	err = client.SendTransaction(ctx, signedTx)
 	if err != nil {
-		return constants.ZeroHash, fmt.Errorf("failed to send transaction: %v", err)
-	}
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && !strings.Contains(err.Error(), "already known") {
+			return constants.ZeroHash, fmt.Errorf("failed to send transaction: %v", err)
+		}
 
-	hash := signedTx.Hash()
+		// a timeout or a dropped connection does not prove the node never got
+		// the transaction, signing again with a new nonce could bridge twice
+		broadcast, lookupErr := wasBroadcast(ctx, client, signedTx, acc.Address)
+		if lookupErr != nil {
+			return hash, fmt.Errorf("%w %s: failed to send transaction: %v (%v)", ErrNotConfirmed, hash, err, lookupErr)
+		}
+		if !broadcast {
+			return constants.ZeroHash, fmt.Errorf("failed to send transaction: %v", err)
+		}
+
+		logger.Warning("Ошибка отправки, но транзакция %s попала в сеть: %v", hash, err)
+	}
 
 	logger.Success("Транзакция успешно отправлена %s%s\n", chainData.ScanURL, hash)
 	fmt.Println()
 
+	// the transaction is on the wire, it is followed even if ctx is cancelled
+	receiptCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), receiptTimeout)
+	defer cancel()
+
+	receipt, err := waitForReceipt(receiptCtx, client, hash)
+	if err != nil {
+		return hash, fmt.Errorf("%w %s: %v", ErrNotConfirmed, hash, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return hash, fmt.Errorf("%w: %s", ErrTransactionReverted, hash)
+	}
+
+	logger.Success("Транзакция подтверждена в блоке %s", receipt.BlockNumber)
+
 	return hash, nil
 }
+
+// wasBroadcast tells whether tx reached the node after a send error that is
+// not a rejection. It did not while the node does not know it and its nonce
+// is still unused.
+func wasBroadcast(ctx context.Context, client *ethclient.Client, tx *types.Transaction, from common.Address) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), chainQueryTimeout)
+	defer cancel()
+
+	_, _, err := client.TransactionByHash(ctx, tx.Hash())
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return false, err
+	}
+
+	pending, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return false, err
+	}
+
+	return pending > tx.Nonce(), nil
+}
+
+func waitForReceipt(ctx context.Context, client *ethclient.Client, hash common.Hash) (*types.Receipt, error) {
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			logger.Warning("Не удалось получить квитанцию %s: %v", hash, err)
+		}
+
+		if err := randomizer.Sleep(ctx, 2*time.Second); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package relay
+
+import (
+	"bytes"
+	"eclipse/configs"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseQuoteTx(t *testing.T) {
+	const to = "0xa5F565650890fBA1824Ee0F21EbBbF660a179934"
+
+	valid := TransactionData{
+		To:                   to,
+		Data:                 "0xdeadbeef",
+		Value:                "1000000000000000",
+		ChainId:              configs.BaseChain.ChainID,
+		MaxFeePerGas:         "2000000000",
+		MaxPriorityFeePerGas: "1000000",
+	}
+	with := func(change func(tx *TransactionData)) TransactionData {
+		tx := valid
+		change(&tx)
+		return tx
+	}
+
+	tests := []struct {
+		name    string
+		tx      TransactionData
+		want    *quoteTx
+		wantErr string
+	}{
+		{
+			name: "full quote",
+			tx:   valid,
+			want: &quoteTx{
+				Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+				Value:     big.NewInt(1_000_000_000_000_000),
+				GasFeeCap: big.NewInt(2_000_000_000),
+				GasTipCap: big.NewInt(1_000_000),
+			},
+		},
+		{
+			name: "wallet priced",
+			tx: with(func(tx *TransactionData) {
+				tx.ChainId = 0
+				tx.Data = ""
+				tx.Value = ""
+				tx.MaxFeePerGas = "0"
+				tx.MaxPriorityFeePerGas = ""
+			}),
+			want: &quoteTx{Value: new(big.Int)},
+		},
+		{
+			name:    "other chain",
+			tx:      with(func(tx *TransactionData) { tx.ChainId = configs.ArbitrumChain.ChainID }),
+			wantErr: "котировка Relay для сети",
+		},
+		{
+			name:    "bad recipient",
+			tx:      with(func(tx *TransactionData) { tx.To = "0x1234" }),
+			wantErr: "некорректное поле to",
+		},
+		{
+			name:    "bad data",
+			tx:      with(func(tx *TransactionData) { tx.Data = "deadbeef" }),
+			wantErr: "некорректное поле data",
+		},
+		{
+			name:    "hex value",
+			tx:      with(func(tx *TransactionData) { tx.Value = "0x10" }),
+			wantErr: "некорректное поле value",
+		},
+		{
+			name:    "negative fee",
+			tx:      with(func(tx *TransactionData) { tx.MaxFeePerGas = "-1" }),
+			wantErr: "некорректное поле maxFeePerGas",
+		},
+		{
+			name:    "tip above fee cap",
+			tx:      with(func(tx *TransactionData) { tx.MaxPriorityFeePerGas = "3000000000" }),
+			wantErr: "maxFeePerGas (2000000000) меньше maxPriorityFeePerGas (3000000000)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuoteTx(tt.tx, configs.BaseChain)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.To.Hex() != tt.tx.To {
+				t.Errorf("To = %s, want %s", got.To.Hex(), tt.tx.To)
+			}
+			if !bytes.Equal(got.Data, tt.want.Data) {
+				t.Errorf("Data = %x, want %x", got.Data, tt.want.Data)
+			}
+			if got.Value.Cmp(tt.want.Value) != 0 {
+				t.Errorf("Value = %s, want %s", got.Value, tt.want.Value)
+			}
+			if !equalFee(got.GasFeeCap, tt.want.GasFeeCap) {
+				t.Errorf("GasFeeCap = %v, want %v", got.GasFeeCap, tt.want.GasFeeCap)
+			}
+			if !equalFee(got.GasTipCap, tt.want.GasTipCap) {
+				t.Errorf("GasTipCap = %v, want %v", got.GasTipCap, tt.want.GasTipCap)
+			}
+		})
+	}
+}
+
+func equalFee(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
@@ -2,42 +2,52 @@
 
 import (
 	"context"
-	"database/sql"
 	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
+	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/randomizer"
-	"eclipse/pkg/services/telegram"
 	"eclipse/utils/balance"
+	"errors"
 	"fmt"
-	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 	"math"
-	"net/http"
+	"math/big"
 	"time"
 )
 
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	cfg configs.RelayConfig,
-	evmAccount *model.EvmAccount,
-	eclipseAccount *model.EclipseAccount,
-	rpcClient *rpc.Client,
-	httpClient http.Client,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Relay",
+		ConfigKey: "relay",
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Relay Bridge")
 
+	cfg := *exec.Config.Relay
+	evmAccount := exec.EvmAccount
+	eclipseAccount := exec.EclipseAccount
+	rpcClient := exec.RpcClient
+	httpClient := exec.HttpClient
+	notifier := exec.Notifier
+	db := exec.DB
+	maxAttempts := exec.MaxAttempts()
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		params := token.SwapInstructions{
 			Payer:         eclipseAccount.PrivateKey,
-			FirstToken:    solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112"),
+			FirstToken:    configs.MustToken("ETH").Address,
 			TokenSymbol:   "ETH",
 			TokenDecimals: 9,
 			IsETH:         true,
@@ -64,13 +74,23 @@ func (m *Module) Execute(
 
 		valueWei, valueStr := randomizer.GetRandomValueWithPrecision(cfg.EthBridge.MinValue, cfg.EthBridge.MaxValue, cfg.EthBridge.MinPrecision, cfg.EthBridge.MaxPrecision, 18)
 
-		randChain := configs.GetRandomChainFromNames(cfg.Networks.Chains)
+		value, ok := new(big.Int).SetString(valueStr, 10)
+		if !ok {
+			return false, fmt.Errorf("invalid bridge amount %q", valueStr)
+		}
 
-		logger.Info("Буду выполнять бридж %s -> Eclipse, %f ETH", randChain.Name, valueWei)
+		source := pickSourceChain(ctx, evmAccount.Address, cfg.Networks, value)
+		if source == nil {
+			logger.Warning("Ни в одной сети из networks.chains не хватает %f ETH с учетом газа. Пропускаем бридж", valueWei)
+			return false, nil
+		}
+		chain := source.Chain
+
+		logger.Info("Буду выполнять бридж %s -> Eclipse, %f ETH", chain.Name, valueWei)
 
 		request := RelayRequest{
 			User:                 evmAccount.Address.String(),
-			OriginChainId:        randChain.ChainID,
+			OriginChainId:        chain.ChainID,
 			DestinationChainId:   constants.DestChainId,
 			OriginCurrency:       constants.ZeroAddress.String(),
 			DestinationCurrency:  constants.DestCurrency,
@@ -81,26 +101,80 @@ func (m *Module) Execute(
 			UseExternalLiquidity: false,
 		}
 
-		response, err := GetRelayData(httpClient, request)
+		quote, err := GetRelayData(httpClient, request)
 		if err != nil {
 			return false, err
 		}
 
-		sig, err := MakeRelayBridge(ctx, *evmAccount, randChain, *response)
-		if err != nil {
+		started := time.Now()
+		requestID := quote.RequestID()
+
+		sig, err := ExecuteQuote(ctx, httpClient, *evmAccount, chain, quote)
+		if err != nil && sig == constants.ZeroHash && !errors.Is(err, ErrQuoteCommitted) {
 			logger.Error("Ошибка бриджа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-			time.Sleep(3 * time.Second)
+			if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+				return false, err
+			}
 			fmt.Println()
 			continue
-		} else {
-			notifier.AddSuccessMessageWithTxLink(
-				eclipseAccount.PublicKey.String(),
-				fmt.Sprintf("Relay Bridge: %s -> Eclipse, %f ETH", randChain.Name, valueWei),
-				randChain.ScanURL,
-				sig.String(),
-			)
-			return true, nil
 		}
+
+		// from here on the deposit was broadcast or the order signed, retrying
+		// could bridge twice
+		if sender.IsDryRun(ctx) && err != nil {
+			return false, err
+		}
+
+		if !sender.IsDryRun(ctx) {
+			result := bridgeResult{Status: bridgeStatus(err)}
+			if err == nil {
+				result, err = trackBridge(ctx, httpClient, rpcClient, params, requestID, balance)
+			}
+
+			// a gasless order has no deposit transaction
+			originTxHash := ""
+			if sig != constants.ZeroHash {
+				originTxHash = sig.Hex()
+			}
+
+			if db != nil && exec.Config.Database.Enabled {
+				dbErr := database.AddBridge(db, database.BridgeRecord{
+					Wallet:       eclipseAccount.PublicKey.String(),
+					Provider:     "Relay",
+					RequestID:    requestID,
+					OriginChain:  chain.Name,
+					OriginTxHash: originTxHash,
+					Destination:  configs.EclipseChain.Name,
+					Token:        "ETH",
+					FillTxHash:   result.FillTxHash,
+					Amount:       valueStr,
+					Status:       result.Status,
+					Elapsed:      time.Since(started),
+				})
+				if dbErr != nil {
+					logger.Error("Failed to add bridge to database: %v", dbErr)
+				}
+			}
+
+			if err != nil {
+				// the funds may be on their way, a retry could send them twice
+				if result.Status == database.BridgeStatusUnknown && !errors.Is(err, sender.ErrTransactionPending) {
+					err = fmt.Errorf("%w: %v", sender.ErrTransactionPending, err)
+				}
+				notifier.AddErrorMessage(eclipseAccount.PublicKey.String(), "Relay Bridge")
+				return false, fmt.Errorf("бридж Relay %s не завершен: %w", requestID, err)
+			}
+
+			logger.Success("Получено %.9f ETH в Eclipse за %s", float64(result.Received)/math.Pow10(9), time.Since(started).Round(time.Second))
+		}
+
+		notifier.AddSuccessMessageWithTxLink(
+			eclipseAccount.PublicKey.String(),
+			fmt.Sprintf("Relay Bridge: %s -> Eclipse, %f ETH", chain.Name, valueWei),
+			chain.ScanURL,
+			sig.String(),
+		)
+		return true, nil
 	}
 
 	notifier.AddErrorMessage(eclipseAccount.PublicKey.String(), "Relay Bridge")
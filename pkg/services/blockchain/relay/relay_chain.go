@@ -0,0 +1,126 @@
+package relay
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/internal/logger"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// bridgeGasReserve is the gas a deposit is budgeted with when choosing the
+// source chain, before Relay returns the actual transaction. It leaves room
+// for the L1 data fee rollups add on top of execution.
+const bridgeGasReserve = 150_000
+
+const chainQueryTimeout = 15 * time.Second
+
+type chainBalance struct {
+	Chain    configs.Chain
+	Balance  *big.Int
+	GasPrice *big.Int
+	Err      error
+}
+
+// gasCost is the budgeted cost of a deposit on the chain.
+func (c chainBalance) gasCost() *big.Int {
+	return new(big.Int).Mul(c.GasPrice, big.NewInt(bridgeGasReserve))
+}
+
+// loadChainBalances queries the native balance and gas price of address on
+// every chain at once.
+func loadChainBalances(ctx context.Context, address common.Address, names []string) []chainBalance {
+	balances := make([]chainBalance, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		chain := configs.GetChainByName(name)
+		if chain == nil {
+			balances[i] = chainBalance{Chain: configs.Chain{Name: name}, Err: fmt.Errorf("неизвестная сеть")}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, chain configs.Chain) {
+			defer wg.Done()
+			balances[i] = loadChainBalance(ctx, address, chain)
+		}(i, *chain)
+	}
+	wg.Wait()
+
+	return balances
+}
+
+func loadChainBalance(ctx context.Context, address common.Address, chain configs.Chain) chainBalance {
+	result := chainBalance{Chain: chain}
+
+	ctx, cancel := context.WithTimeout(ctx, chainQueryTimeout)
+	defer cancel()
+
+	client, err := ethclient.DialContext(ctx, chain.RPC)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to connect to the %s client: %v", chain.Name, err)
+		return result
+	}
+	defer client.Close()
+
+	result.Balance, err = client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to get balance: %v", err)
+		return result
+	}
+
+	result.GasPrice, err = client.SuggestGasPrice(ctx)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to get gas price: %v", err)
+	}
+
+	return result
+}
+
+// pickSourceChain returns a chain whose balance covers value plus gas, a
+// random one or the one with the cheapest gas. It returns nil when no chain
+// can pay for the bridge.
+func pickSourceChain(ctx context.Context, address common.Address, networks configs.NetworkConfig, value *big.Int) *chainBalance {
+	var eligible []chainBalance
+	for _, c := range loadChainBalances(ctx, address, networks.Chains) {
+		if c.Err != nil {
+			logger.Warning("Не удалось проверить баланс в %s: %v", c.Chain.Name, c.Err)
+			continue
+		}
+
+		need := new(big.Int).Add(value, c.gasCost())
+		logger.Info("Баланс в %s: %s ETH, нужно %s ETH", c.Chain.Name, formatEther(c.Balance), formatEther(need))
+
+		if c.Balance.Cmp(need) >= 0 {
+			eligible = append(eligible, c)
+		}
+	}
+
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	if !networks.CheapestGas {
+		return &eligible[rand.Intn(len(eligible))]
+	}
+
+	cheapest := &eligible[0]
+	for i := range eligible[1:] {
+		if eligible[i+1].gasCost().Cmp(cheapest.gasCost()) < 0 {
+			cheapest = &eligible[i+1]
+		}
+	}
+
+	return cheapest
+}
+
+func formatEther(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Text('f', 6)
+}
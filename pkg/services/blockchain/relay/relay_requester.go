@@ -5,7 +5,10 @@ import (
 	"eclipse/internal/logger"
 	"encoding/json"
 	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"io"
 	"net/http"
+	"net/url"
 )
 
 type RelayRequest struct {
@@ -31,21 +34,94 @@ type TransactionData struct {
 	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
 }
 
-type RelayResponse struct {
-	Steps []struct {
-		Items []struct {
-			Data TransactionData `json:"data"`
-		} `json:"items"`
-	} `json:"steps"`
+const relayAPI = "https://api.relay.link"
+
+const (
+	StepKindTransaction = "transaction"
+	StepKindSignature   = "signature"
+
+	ItemStatusComplete = "complete"
+)
+
+// Step is one stage of a quote, e.g. an approval, a signature or the
+// deposit. Its items are executed in order.
+type Step struct {
+	ID          string     `json:"id"`
+	Action      string     `json:"action"`
+	Description string     `json:"description"`
+	Kind        string     `json:"kind"`
+	RequestID   string     `json:"requestId"`
+	Items       []StepItem `json:"items"`
+}
+
+// StepItem carries a TransactionData, or a SolanaTransactionData when the
+// origin is Eclipse, for transaction steps and a SignatureData for
+// signature steps.
+type StepItem struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+	Check  *StepCheck      `json:"check"`
+}
+
+// StepCheck is the endpoint reporting whether an item was processed.
+type StepCheck struct {
+	Endpoint string `json:"endpoint"`
+	Method   string `json:"method"`
 }
 
-func GetRelayData(client http.Client, request RelayRequest) (*TransactionData, error) {
+// SolanaTransactionData is a transaction item of a quote from Eclipse. Relay
+// returns the instructions and lookup tables, the transaction is compiled
+// and signed by the client.
+type SolanaTransactionData struct {
+	Instructions []struct {
+		ProgramID solana.PublicKey `json:"programId"`
+		Keys      []struct {
+			Pubkey     solana.PublicKey `json:"pubkey"`
+			IsSigner   bool             `json:"isSigner"`
+			IsWritable bool             `json:"isWritable"`
+		} `json:"keys"`
+		Data string `json:"data"`
+	} `json:"instructions"`
+	AddressLookupTableAddresses []solana.PublicKey `json:"addressLookupTableAddresses"`
+}
+
+type SignatureData struct {
+	Sign struct {
+		SignatureKind string          `json:"signatureKind"`
+		Message       string          `json:"message"`
+		Domain        json.RawMessage `json:"domain"`
+		Types         json.RawMessage `json:"types"`
+		PrimaryType   string          `json:"primaryType"`
+		Value         json.RawMessage `json:"value"`
+	} `json:"sign"`
+	Post struct {
+		Endpoint string          `json:"endpoint"`
+		Method   string          `json:"method"`
+		Body     json.RawMessage `json:"body"`
+	} `json:"post"`
+}
+
+type RelayQuote struct {
+	Steps []Step `json:"steps"`
+}
+
+// RequestID is the id Relay tracks the fill of the quote under.
+func (q *RelayQuote) RequestID() string {
+	for i := len(q.Steps) - 1; i >= 0; i-- {
+		if q.Steps[i].RequestID != "" {
+			return q.Steps[i].RequestID
+		}
+	}
+	return ""
+}
+
+func GetRelayData(client http.Client, request RelayRequest) (*RelayQuote, error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.relay.link/quote", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", relayAPI+"/quote", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -65,15 +141,107 @@ func GetRelayData(client http.Client, request RelayRequest) (*TransactionData, e
 	}
 	defer resp.Body.Close()
 
-	var relayResponse RelayResponse
-	if err := json.NewDecoder(resp.Body).Decode(&relayResponse); err != nil {
+	var quote RelayQuote
+	if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
 		return nil, fmt.Errorf("error decoding response: %w", err)
 	}
 
-	if len(relayResponse.Steps) == 0 || len(relayResponse.Steps[0].Items) == 0 {
-		return nil, fmt.Errorf("no transaction data in response")
+	if len(quote.Steps) == 0 {
+		return nil, fmt.Errorf("no steps in response")
+	}
+	for _, step := range quote.Steps {
+		if len(step.Items) == 0 {
+			return nil, fmt.Errorf("step %q has no items", step.ID)
+		}
+	}
+	if quote.RequestID() == "" {
+		return nil, fmt.Errorf("no request id in response")
 	}
 
 	logger.Success("Успешно получил данные для выполнение Relay бриджа")
-	return &relayResponse.Steps[0].Items[0].Data, nil
+	return &quote, nil
+}
+
+type IntentStatus struct {
+	Status     string   `json:"status"`
+	Details    string   `json:"details"`
+	InTxHashes []string `json:"inTxHashes"`
+	TxHashes   []string `json:"txHashes"`
+}
+
+// IntentStatusCheck is the check reporting the fill of requestID.
+func IntentStatusCheck(requestID string) StepCheck {
+	return StepCheck{
+		Endpoint: "/intents/status/v2?requestId=" + url.QueryEscape(requestID),
+		Method:   "GET",
+	}
+}
+
+// GetCheckStatus calls the check endpoint of a step item.
+func GetCheckStatus(client http.Client, check StepCheck) (*IntentStatus, error) {
+	method := check.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	req, err := http.NewRequest(method, relayAPI+check.Endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	SetRequestHeaders(req, "https://relay.link/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var status IntentStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return &status, nil
+}
+
+// PostSignature submits a signed item to the endpoint the step names, the
+// signature goes in the query as Relay expects.
+func PostSignature(client http.Client, data SignatureData, signature string) error {
+	method := data.Post.Method
+	if method == "" {
+		method = "POST"
+	}
+
+	endpoint, err := url.Parse(relayAPI + data.Post.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid signature endpoint %q: %w", data.Post.Endpoint, err)
+	}
+	query := endpoint.Query()
+	query.Set("signature", signature)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, endpoint.String(), bytes.NewReader(data.Post.Body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	SetRequestHeaders(req, "https://relay.link/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
 }
@@ -0,0 +1,141 @@
+package relay
+
+import (
+	"context"
+	"eclipse/constants"
+	"eclipse/internal/logger"
+	"eclipse/model"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ExecuteSolanaQuote runs every step of a quote whose origin is Eclipse.
+// Transaction items are compiled against the lookup tables Relay names,
+// priced by policy and signed by acc, and an item's check has to report
+// success before the next item starts.
+//
+// The returned signature is the last transaction that landed or may have
+// landed. A non-zero signature together with an error means the funds may
+// have left the account and the quote must not be executed again.
+func ExecuteSolanaQuote(ctx context.Context, httpClient http.Client, client *rpc.Client, acc model.EclipseAccount, quote *RelayQuote, policy *fees.Policy) (solana.Signature, error) {
+	logger.Info("Произвожу вызов функции для Relay вывода")
+
+	var deposit solana.Signature
+	for _, step := range quote.Steps {
+		for i, item := range step.Items {
+			if item.Status == ItemStatusComplete {
+				continue
+			}
+
+			logger.Info("Relay шаг %s (%d/%d): %s", step.ID, i+1, len(step.Items), step.Description)
+
+			if step.Kind != StepKindTransaction {
+				return deposit, fmt.Errorf("шаг %s: тип %q не поддерживается для Eclipse", step.ID, step.Kind)
+			}
+
+			var data SolanaTransactionData
+			if err := json.Unmarshal(item.Data, &data); err != nil {
+				return deposit, fmt.Errorf("некорректная транзакция в шаге %s: %v", step.ID, err)
+			}
+
+			sig, err := sendSolanaTransaction(ctx, client, acc, data, policy)
+			if !sig.IsZero() {
+				deposit = sig
+			}
+			if err != nil {
+				return deposit, fmt.Errorf("шаг %s: %w", step.ID, err)
+			}
+
+			if item.Check != nil && !sender.IsDryRun(ctx) {
+				if _, err := waitForCheck(ctx, httpClient, *item.Check); err != nil {
+					return deposit, fmt.Errorf("шаг %s: %w", step.ID, err)
+				}
+			}
+		}
+	}
+
+	return deposit, nil
+}
+
+// sendSolanaTransaction returns a signature only when the transaction
+// landed or its outcome is unknown; a failed, expired or rejected one is
+// safe to build again.
+func sendSolanaTransaction(ctx context.Context, client *rpc.Client, acc model.EclipseAccount, data SolanaTransactionData, policy *fees.Policy) (solana.Signature, error) {
+	instructions, err := data.instructions(acc.PublicKey)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	var tables sender.LookupTables
+	if len(data.AddressLookupTableAddresses) > 0 {
+		tables, err = sender.LoadLookupTables(ctx, client, data.AddressLookupTableAddresses)
+		if err != nil {
+			return solana.Signature{}, err
+		}
+	}
+
+	instructions, err = policy.Apply(ctx, instructions, acc.PublicKey, tables)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("error applying priority fee: %v", err)
+	}
+
+	tx, lastValidBlockHeight, err := sender.BuildTransaction(ctx, client, instructions, acc.PrivateKey, tables)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	opts := sender.DefaultOptions()
+	opts.LastValidBlockHeight = lastValidBlockHeight
+	opts.Tracker = sender.NewTracker(client)
+
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		if errors.Is(err, sender.ErrTransactionPending) {
+			return result.Signature, fmt.Errorf("error sending transaction: %w", err)
+		}
+		return solana.Signature{}, fmt.Errorf("error sending transaction: %w", err)
+	}
+
+	if !sender.IsDryRun(ctx) {
+		logger.Success("Транзакция Relay подтверждена: %s%s", constants.EclipseScan, result.Signature)
+	}
+
+	return result.Signature, nil
+}
+
+// instructions decodes the item for payer, which has to be the only signer
+// Relay asks for.
+func (d SolanaTransactionData) instructions(payer solana.PublicKey) ([]solana.Instruction, error) {
+	if len(d.Instructions) == 0 {
+		return nil, fmt.Errorf("в транзакции нет инструкций")
+	}
+
+	instructions := make([]solana.Instruction, 0, len(d.Instructions))
+	for _, inst := range d.Instructions {
+		data, err := hex.DecodeString(strings.TrimPrefix(inst.Data, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("некорректные данные инструкции %s: %v", inst.ProgramID, err)
+		}
+
+		accounts := make(solana.AccountMetaSlice, 0, len(inst.Keys))
+		for _, key := range inst.Keys {
+			if key.IsSigner && !key.Pubkey.Equals(payer) {
+				return nil, fmt.Errorf("Relay ожидает подпись %s", key.Pubkey)
+			}
+			accounts = append(accounts, solana.NewAccountMeta(key.Pubkey, key.IsWritable, key.IsSigner))
+		}
+
+		instructions = append(instructions, solana.NewInstruction(inst.ProgramID, accounts, data))
+	}
+
+	return instructions, nil
+}
@@ -0,0 +1,188 @@
+package relay
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/constants"
+	"eclipse/internal/logger"
+	"eclipse/model"
+	"eclipse/pkg/services/blockchain/sender"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// approveStepID is the step granting Relay an allowance. Sending it again is
+// harmless, unlike any other transaction of a quote.
+const approveStepID = "approve"
+
+// ErrQuoteCommitted marks a failure after Relay accepted a signed order of
+// the quote. The order may still be filled, so the quote must not be
+// executed again.
+var ErrQuoteCommitted = errors.New("relay order already submitted")
+
+// ExecuteQuote runs every step of quote on chainData in order. Transaction
+// items are sent and confirmed, signature items are signed and posted, and
+// an item's check has to report success before the next item starts.
+//
+// The returned hash is the last transaction sent outside an approval step.
+// A non-zero hash or ErrQuoteCommitted together with an error means the
+// deposit was broadcast or the order signed and the quote must not be
+// executed again.
+func ExecuteQuote(ctx context.Context, httpClient http.Client, acc model.EvmAccount, chainData configs.Chain, quote *RelayQuote) (common.Hash, error) {
+	logger.Info("Произвожу вызов функции для Relay бриджа")
+
+	client, err := ethclient.Dial(chainData.RPC)
+	if err != nil {
+		return constants.ZeroHash, fmt.Errorf("failed to connect to the %s client: %v", chainData.Name, err)
+	}
+	defer client.Close()
+
+	deposit := constants.ZeroHash
+	committed := false
+	fail := func(err error) (common.Hash, error) {
+		if committed {
+			err = fmt.Errorf("%w: %w", ErrQuoteCommitted, err)
+		}
+		return deposit, err
+	}
+
+	for _, step := range quote.Steps {
+		for i, item := range step.Items {
+			if item.Status == ItemStatusComplete {
+				continue
+			}
+
+			logger.Info("Relay шаг %s (%d/%d): %s", step.ID, i+1, len(step.Items), step.Description)
+
+			switch step.Kind {
+			case StepKindTransaction:
+				var txData TransactionData
+				if err := json.Unmarshal(item.Data, &txData); err != nil {
+					return fail(fmt.Errorf("некорректная транзакция в шаге %s: %v", step.ID, err))
+				}
+
+				hash, err := sendTransaction(ctx, client, acc, chainData, txData)
+				if hash != constants.ZeroHash && step.ID != approveStepID {
+					deposit = hash
+				}
+				if err != nil {
+					return fail(fmt.Errorf("шаг %s: %w", step.ID, err))
+				}
+
+			case StepKindSignature:
+				var data SignatureData
+				if err := json.Unmarshal(item.Data, &data); err != nil {
+					return fail(fmt.Errorf("некорректная подпись в шаге %s: %v", step.ID, err))
+				}
+
+				if err := signAndPost(ctx, httpClient, acc, data); err != nil {
+					return fail(fmt.Errorf("шаг %s: %w", step.ID, err))
+				}
+				if !sender.IsDryRun(ctx) {
+					committed = true
+				}
+
+			default:
+				return fail(fmt.Errorf("неизвестный тип шага %s: %q", step.ID, step.Kind))
+			}
+
+			if item.Check != nil && !sender.IsDryRun(ctx) {
+				if _, err := waitForCheck(ctx, httpClient, *item.Check); err != nil {
+					return fail(fmt.Errorf("шаг %s: %w", step.ID, err))
+				}
+			}
+		}
+	}
+
+	return deposit, nil
+}
+
+func signAndPost(ctx context.Context, httpClient http.Client, acc model.EvmAccount, data SignatureData) error {
+	var hash []byte
+	switch data.Sign.SignatureKind {
+	case "eip191":
+		message := []byte(data.Sign.Message)
+		if decoded, err := hexutil.Decode(data.Sign.Message); err == nil {
+			message = decoded
+		}
+		hash = accounts.TextHash(message)
+
+	case "eip712":
+		var err error
+		hash, err = typedDataHash(data)
+		if err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("неизвестный тип подписи %q", data.Sign.SignatureKind)
+	}
+
+	signature, err := crypto.Sign(hash, acc.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign message: %v", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	if sender.IsDryRun(ctx) {
+		logger.Info("[dry-run] Подпись %s готова, в Relay не отправлена", data.Sign.SignatureKind)
+		return nil
+	}
+
+	if err := PostSignature(httpClient, data, hexutil.Encode(signature)); err != nil {
+		return fmt.Errorf("failed to post signature: %v", err)
+	}
+
+	logger.Success("Подпись отправлена в Relay")
+
+	return nil
+}
+
+// typedDataHash hashes an EIP-712 payload. Relay leaves EIP712Domain out of
+// the types, so it is derived from the domain fields that are set.
+func typedDataHash(data SignatureData) ([]byte, error) {
+	var typed apitypes.TypedData
+	if err := json.Unmarshal(data.Sign.Types, &typed.Types); err != nil {
+		return nil, fmt.Errorf("invalid eip712 types: %v", err)
+	}
+	if err := json.Unmarshal(data.Sign.Domain, &typed.Domain); err != nil {
+		return nil, fmt.Errorf("invalid eip712 domain: %v", err)
+	}
+	if err := json.Unmarshal(data.Sign.Value, &typed.Message); err != nil {
+		return nil, fmt.Errorf("invalid eip712 value: %v", err)
+	}
+	typed.PrimaryType = data.Sign.PrimaryType
+
+	if _, ok := typed.Types["EIP712Domain"]; !ok {
+		var domain []apitypes.Type
+		fields := typed.Domain.Map()
+		for _, field := range []apitypes.Type{
+			{Name: "name", Type: "string"},
+			{Name: "version", Type: "string"},
+			{Name: "chainId", Type: "uint256"},
+			{Name: "verifyingContract", Type: "address"},
+			{Name: "salt", Type: "bytes32"},
+		} {
+			if _, ok := fields[field.Name]; ok {
+				domain = append(domain, field)
+			}
+		}
+		typed.Types["EIP712Domain"] = domain
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash eip712 data: %v", err)
+	}
+
+	return hash, nil
+}
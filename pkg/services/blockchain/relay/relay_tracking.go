@@ -0,0 +1,159 @@
+package relay
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/constants"
+	"eclipse/internal/logger"
+	"eclipse/internal/token"
+	"eclipse/pkg/services/database"
+	"eclipse/pkg/services/randomizer"
+	"eclipse/utils/balance"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	trackPollInterval = 5 * time.Second
+	fillTimeout       = 15 * time.Minute
+	arrivalTimeout    = 5 * time.Minute
+)
+
+var (
+	ErrFillFailed   = errors.New("relay failed to fill the request")
+	ErrFillRefunded = errors.New("relay refunded the request")
+	ErrFillTimeout  = errors.New("relay did not fill the request in time")
+)
+
+type bridgeResult struct {
+	FillTxHash string
+	Received   uint64
+	Status     string
+}
+
+// trackBridge waits until Relay fills requestID and the ETH shows up on the
+// Eclipse account params describes. before is the account balance prior to
+// the deposit.
+func trackBridge(ctx context.Context, httpClient http.Client, rpcClient *rpc.Client, params token.SwapInstructions, requestID string, before uint64) (bridgeResult, error) {
+	result := bridgeResult{Status: database.BridgeStatusUnknown}
+
+	status, err := waitForFill(ctx, httpClient, requestID)
+	if err != nil {
+		result.Status = bridgeStatus(err)
+		return result, err
+	}
+
+	if len(status.TxHashes) > 0 {
+		result.FillTxHash = status.TxHashes[0]
+		logger.Success("Relay выполнил бридж: %s%s", constants.EclipseScan, result.FillTxHash)
+	}
+
+	received, err := waitForArrival(ctx, rpcClient, params, before)
+	if err != nil {
+		return result, err
+	}
+
+	result.Received = received
+	result.Status = database.BridgeStatusFilled
+
+	return result, nil
+}
+
+// trackWithdraw waits until Relay fills requestID on chain. Relay reports
+// success once the funds were sent to the recipient there.
+func trackWithdraw(ctx context.Context, httpClient http.Client, requestID string, chain configs.Chain) (bridgeResult, error) {
+	status, err := waitForFill(ctx, httpClient, requestID)
+	if err != nil {
+		return bridgeResult{Status: bridgeStatus(err)}, err
+	}
+
+	result := bridgeResult{Status: database.BridgeStatusFilled}
+	if len(status.TxHashes) > 0 {
+		result.FillTxHash = status.TxHashes[0]
+		logger.Success("Relay выполнил вывод: %s%s", chain.ScanURL, result.FillTxHash)
+	}
+
+	return result, nil
+}
+
+// bridgeStatus is the status a bridge that stopped with err is recorded
+// with.
+func bridgeStatus(err error) string {
+	switch {
+	case errors.Is(err, ErrFillFailed):
+		return database.BridgeStatusFailed
+	case errors.Is(err, ErrFillRefunded):
+		return database.BridgeStatusRefunded
+	default:
+		return database.BridgeStatusUnknown
+	}
+}
+
+func waitForFill(ctx context.Context, httpClient http.Client, requestID string) (*IntentStatus, error) {
+	logger.Info("Жду исполнения Relay запроса %s", requestID)
+
+	return waitForCheck(ctx, httpClient, IntentStatusCheck(requestID))
+}
+
+// waitForCheck polls a check endpoint until it reports success, failure or
+// a refund.
+func waitForCheck(ctx context.Context, httpClient http.Client, check StepCheck) (*IntentStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, fillTimeout)
+	defer cancel()
+
+	last := ""
+	for {
+		status, err := GetCheckStatus(httpClient, check)
+		if err != nil {
+			logger.Warning("Не удалось получить статус Relay запроса: %v", err)
+		} else {
+			if status.Status != last {
+				logger.Info("Статус Relay запроса: %s", status.Status)
+				last = status.Status
+			}
+
+			switch status.Status {
+			case "success":
+				return status, nil
+			case "failure":
+				return nil, fmt.Errorf("%w: %s", ErrFillFailed, status.Details)
+			case "refund":
+				return nil, fmt.Errorf("%w: %s", ErrFillRefunded, status.Details)
+			}
+		}
+
+		if err := randomizer.Sleep(ctx, trackPollInterval); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: последний статус %q", ErrFillTimeout, last)
+			}
+			return nil, err
+		}
+	}
+}
+
+// waitForArrival polls the Eclipse balance until it grows past before and
+// returns by how much.
+func waitForArrival(ctx context.Context, client *rpc.Client, params token.SwapInstructions, before uint64) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, arrivalTimeout)
+	defer cancel()
+
+	for {
+		current, err := balance.GetTokenBalance(ctx, client, params)
+		if err != nil {
+			logger.Warning("Не удалось проверить баланс ETH в Eclipse: %v", err)
+		} else if current > before {
+			return current - before, nil
+		}
+
+		if err := randomizer.Sleep(ctx, trackPollInterval); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return 0, fmt.Errorf("ETH не поступил на Eclipse за %s", arrivalTimeout)
+			}
+			return 0, err
+		}
+	}
+}
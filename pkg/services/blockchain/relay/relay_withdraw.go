@@ -0,0 +1,268 @@
+package relay
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/constants"
+	"eclipse/internal/logger"
+	"eclipse/internal/token"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
+	"eclipse/pkg/services/database"
+	"eclipse/pkg/services/randomizer"
+	"eclipse/utils/balance"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// withdrawComputeUnits is the compute budget the fee of a withdrawal is
+// reserved for before Relay returns the actual instructions.
+const withdrawComputeUnits = 200_000
+
+type WithdrawModule struct{}
+
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Relay Withdraw",
+		ConfigKey: "relay_withdraw",
+		Module:    &WithdrawModule{},
+	})
+}
+
+// withdrawal is a transfer of Token from Eclipse to the EVM account on
+// Chain. Value is Amount in the token's base units.
+type withdrawal struct {
+	Token  configs.Token
+	Amount float64
+	Value  string
+	Chain  configs.Chain
+}
+
+func (w withdrawal) originCurrency() string {
+	if w.Token.Symbol == "ETH" {
+		return constants.DestCurrency
+	}
+	return w.Token.Address.String()
+}
+
+func (w withdrawal) destinationCurrency() string {
+	return destinationCurrency(w.Token.Symbol, w.Chain)
+}
+
+func destinationCurrency(symbol string, chain configs.Chain) string {
+	if symbol == "ETH" {
+		return constants.ZeroAddress.String()
+	}
+	return chain.USDC
+}
+
+func (m *WithdrawModule) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
+	logger.Info("Начал выполнение модуля Relay Withdraw")
+
+	cfg := exec.Config.Relay.Withdraw
+	evmAccount := exec.EvmAccount
+	eclipseAccount := exec.EclipseAccount
+	rpcClient := exec.RpcClient
+	httpClient := exec.HttpClient
+	notifier := exec.Notifier
+	db := exec.DB
+	maxAttempts := exec.MaxAttempts()
+	policy := exec.FeePolicy()
+
+	feeReserve := fees.Reserve(*exec.Config.Fees, withdrawComputeUnits)
+
+	w, err := pickWithdrawal(ctx, rpcClient, eclipseAccount.PrivateKey, cfg, exec.Config.MinEthHold, feeReserve)
+	if err != nil {
+		return false, err
+	}
+	if w == nil {
+		logger.Warning("Ни одного токена из relay_withdraw.tokens не хватает на вывод. Пропускаем вывод")
+		return false, nil
+	}
+
+	logger.Info("Буду выполнять вывод Eclipse -> %s, %f %s на %s", w.Chain.Name, w.Amount, w.Token.Symbol, evmAccount.Address)
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		request := RelayRequest{
+			User:                 eclipseAccount.PublicKey.String(),
+			OriginChainId:        configs.EclipseChain.ChainID,
+			DestinationChainId:   w.Chain.ChainID,
+			OriginCurrency:       w.originCurrency(),
+			DestinationCurrency:  w.destinationCurrency(),
+			Recipient:            evmAccount.Address.String(),
+			TradeType:            constants.TradeInput,
+			Amount:               w.Value,
+			Referrer:             constants.Referrer,
+			UseExternalLiquidity: false,
+		}
+
+		quote, err := GetRelayData(httpClient, request)
+		if err != nil {
+			return false, err
+		}
+
+		started := time.Now()
+		requestID := quote.RequestID()
+
+		sig, err := ExecuteSolanaQuote(ctx, httpClient, rpcClient, *eclipseAccount, quote, policy)
+		if err != nil && sig.IsZero() {
+			logger.Error("Ошибка вывода (попытка %d/%d): %v", attempt+1, maxAttempts, err)
+			if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+				return false, err
+			}
+			fmt.Println()
+			continue
+		}
+
+		// from here on the funds may have left Eclipse, retrying could withdraw twice
+		if sender.IsDryRun(ctx) && err != nil {
+			return false, err
+		}
+
+		if !sender.IsDryRun(ctx) {
+			result := bridgeResult{Status: bridgeStatus(err)}
+			if err == nil {
+				result, err = trackWithdraw(ctx, httpClient, requestID, w.Chain)
+			}
+
+			if db != nil && exec.Config.Database.Enabled {
+				dbErr := database.AddBridge(db, database.BridgeRecord{
+					Wallet:       eclipseAccount.PublicKey.String(),
+					Provider:     "Relay",
+					RequestID:    requestID,
+					OriginChain:  configs.EclipseChain.Name,
+					OriginTxHash: sig.String(),
+					Destination:  w.Chain.Name,
+					Token:        w.Token.Symbol,
+					FillTxHash:   result.FillTxHash,
+					Amount:       w.Value,
+					Status:       result.Status,
+					Elapsed:      time.Since(started),
+				})
+				if dbErr != nil {
+					logger.Error("Failed to add bridge to database: %v", dbErr)
+				}
+			}
+
+			if err != nil {
+				// the funds may be on their way, a retry could send them twice
+				if result.Status == database.BridgeStatusUnknown && !errors.Is(err, sender.ErrTransactionPending) {
+					err = fmt.Errorf("%w: %v", sender.ErrTransactionPending, err)
+				}
+				notifier.AddErrorMessage(eclipseAccount.PublicKey.String(), "Relay Withdraw")
+				return false, fmt.Errorf("вывод Relay %s не завершен: %w", requestID, err)
+			}
+
+			logger.Success("Вывод %f %s в %s выполнен за %s", w.Amount, w.Token.Symbol, w.Chain.Name, time.Since(started).Round(time.Second))
+		}
+
+		notifier.AddSuccessMessageWithTxLink(
+			eclipseAccount.PublicKey.String(),
+			fmt.Sprintf("Relay Withdraw: Eclipse -> %s, %f %s", w.Chain.Name, w.Amount, w.Token.Symbol),
+			constants.EclipseScan,
+			sig.String(),
+		)
+		return true, nil
+	}
+
+	notifier.AddErrorMessage(eclipseAccount.PublicKey.String(), "Relay Withdraw")
+	return false, fmt.Errorf("could not execute withdraw after %d attempts", maxAttempts)
+}
+
+// pickWithdrawal picks a random token from cfg whose Eclipse balance covers
+// a random amount and a random chain that can receive it. The ETH balance
+// has to cover feeReserve as well, plus minEthHold when ETH is withdrawn.
+// It returns nil when no token can be withdrawn.
+func pickWithdrawal(ctx context.Context, client *rpc.Client, payer solana.PrivateKey, cfg configs.WithdrawConfig, minEthHold float64, feeReserve uint64) (*withdrawal, error) {
+	eth := token.SwapInstructions{
+		Payer:         payer,
+		FirstToken:    configs.MustToken("ETH").Address,
+		IsETH:         true,
+		TokenSymbol:   "ETH",
+		TokenDecimals: configs.MustToken("ETH").Decimals,
+	}
+
+	ethBalance, err := balance.GetTokenBalance(ctx, client, eth)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке баланса ETH: %v", err)
+	}
+	if ethBalance < feeReserve {
+		logger.Info("Баланс ETH в Eclipse (%.9f) не покрывает комиссию вывода (%.9f)",
+			float64(ethBalance)/float64(solana.LAMPORTS_PER_SOL),
+			float64(feeReserve)/float64(solana.LAMPORTS_PER_SOL))
+		return nil, nil
+	}
+
+	symbols := append([]string(nil), cfg.Tokens...)
+	rand.Shuffle(len(symbols), func(i, j int) {
+		symbols[i], symbols[j] = symbols[j], symbols[i]
+	})
+
+	for _, symbol := range symbols {
+		t := configs.MustToken(symbol)
+
+		var chains []configs.Chain
+		for _, name := range cfg.Chains {
+			chain := configs.GetChainByName(name)
+			if chain != nil && destinationCurrency(symbol, *chain) != "" {
+				chains = append(chains, *chain)
+			}
+		}
+		if len(chains) == 0 {
+			logger.Warning("Ни одна сеть из relay_withdraw.chains не принимает %s", symbol)
+			continue
+		}
+
+		amountCfg := cfg.Amount(symbol)
+		amount, value := randomizer.GetRandomValueWithPrecision(amountCfg.MinValue, amountCfg.MaxValue, amountCfg.MinPrecision, amountCfg.MaxPrecision, float64(t.Decimals))
+
+		need, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || need == 0 {
+			return nil, fmt.Errorf("invalid withdraw amount %q", value)
+		}
+
+		current := ethBalance
+		if symbol == "ETH" {
+			need += feeReserve + uint64(minEthHold*math.Pow10(int(t.Decimals)))
+		} else {
+			current, err = balance.GetTokenBalance(ctx, client, token.SwapInstructions{
+				Payer:         payer,
+				FirstToken:    t.Address,
+				TokenSymbol:   symbol,
+				TokenDecimals: t.Decimals,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при проверке баланса %s: %v", symbol, err)
+			}
+		}
+
+		if current < need {
+			logger.Info("Баланс %s в Eclipse (%.6f) меньше нужного для вывода (%.6f)",
+				symbol,
+				float64(current)/math.Pow10(int(t.Decimals)),
+				float64(need)/math.Pow10(int(t.Decimals)))
+			continue
+		}
+
+		return &withdrawal{
+			Token:  t,
+			Amount: amount,
+			Value:  value,
+			Chain:  chains[rand.Intn(len(chains))],
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,99 @@
+package sender
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var AddressLookupTableProgramID = solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
+
+// MaxLookupTableAddresses is how many addresses one lookup table can hold.
+const MaxLookupTableAddresses = 256
+
+// lookupTableAuthorityOffset is where a lookup table stores its authority,
+// after the type, the deactivation and last extended slots, the start index
+// and the option tag.
+const lookupTableAuthorityOffset = 22
+
+// FindLookupTables returns the active lookup tables owned by authority.
+func FindLookupTables(ctx context.Context, client *rpc.Client, authority solana.PublicKey) (LookupTables, error) {
+	accounts, err := client.GetProgramAccountsWithOpts(ctx, AddressLookupTableProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{{
+			Memcmp: &rpc.RPCFilterMemcmp{Offset: lookupTableAuthorityOffset, Bytes: authority.Bytes()},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось найти lookup таблицы %s: %v", authority, err)
+	}
+
+	tables := make(LookupTables, len(accounts))
+	for _, account := range accounts {
+		state, err := addresslookuptable.DecodeAddressLookupTableState(account.Account.Data.GetBinary())
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать lookup таблицу %s: %v", account.Pubkey, err)
+		}
+		if state.IsActive() {
+			tables[account.Pubkey] = state.Addresses
+		}
+	}
+
+	return tables, nil
+}
+
+// CreateLookupTableInstruction returns an instruction creating a lookup
+// table owned by authority, together with the table address. slot has to be
+// a recent slot, the table address is derived from it.
+func CreateLookupTableInstruction(authority, payer solana.PublicKey, slot uint64) (solana.Instruction, solana.PublicKey, error) {
+	slotBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(slotBytes, slot)
+
+	table, bump, err := solana.FindProgramAddress([][]byte{authority.Bytes(), slotBytes}, AddressLookupTableProgramID)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+
+	data := make([]byte, 13)
+	binary.LittleEndian.PutUint32(data[0:4], 0)
+	copy(data[4:12], slotBytes)
+	data[12] = bump
+
+	return solana.NewInstruction(
+		AddressLookupTableProgramID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(table, true, false),
+			solana.NewAccountMeta(authority, false, true),
+			solana.NewAccountMeta(payer, true, true),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+		},
+		data,
+	), table, nil
+}
+
+// ExtendLookupTableInstruction returns an instruction appending addresses to
+// table. They can be looked up from the slot after the one it lands in.
+func ExtendLookupTableInstruction(table, authority, payer solana.PublicKey, addresses []solana.PublicKey) solana.Instruction {
+	data := make([]byte, 12, 12+len(addresses)*solana.PublicKeyLength)
+	binary.LittleEndian.PutUint32(data[0:4], 2)
+	binary.LittleEndian.PutUint64(data[4:12], uint64(len(addresses)))
+	for _, address := range addresses {
+		data = append(data, address.Bytes()...)
+	}
+
+	return solana.NewInstruction(
+		AddressLookupTableProgramID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(table, true, false),
+			solana.NewAccountMeta(authority, false, true),
+			solana.NewAccountMeta(payer, true, true),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+		},
+		data,
+	)
+}
@@ -0,0 +1,50 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestLookupTableInstructions(t *testing.T) {
+	authority := solana.NewWallet().PublicKey()
+	const slot = 123_456
+
+	create, table, err := CreateLookupTableInstruction(authority, authority, slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slotBytes := binary.LittleEndian.AppendUint64(nil, slot)
+	want, bump, err := solana.FindProgramAddress([][]byte{authority.Bytes(), slotBytes}, AddressLookupTableProgramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !table.Equals(want) {
+		t.Errorf("table = %s, want %s", table, want)
+	}
+
+	data, _ := create.Data()
+	if wantData := append(append([]byte{0, 0, 0, 0}, slotBytes...), bump); !bytes.Equal(data, wantData) {
+		t.Errorf("create data = %x, want %x", data, wantData)
+	}
+
+	addresses := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+	extend := ExtendLookupTableInstruction(table, authority, authority, addresses)
+
+	data, _ = extend.Data()
+	wantData := []byte{2, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}
+	wantData = append(append(wantData, addresses[0].Bytes()...), addresses[1].Bytes()...)
+	if !bytes.Equal(data, wantData) {
+		t.Errorf("extend data = %x, want %x", data, wantData)
+	}
+
+	for name, inst := range map[string]solana.Instruction{"create": create, "extend": extend} {
+		accounts := inst.Accounts()
+		if len(accounts) != 4 || !accounts[0].PublicKey.Equals(table) || !accounts[0].IsWritable || !accounts[1].IsSigner || !accounts[2].IsSigner {
+			t.Errorf("%s accounts = %v, want table, authority, payer and system program", name, accounts)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type RewriteOptions struct {
+	// FeePayer replaces the fee payer chosen by the server. The zero value
+	// keeps it.
+	FeePayer solana.PublicKey
+	// RefreshBlockhash replaces the server's blockhash with a fresh one.
+	RefreshBlockhash bool
+}
+
+// Rewrite prepares a transaction built by a third party API for sending.
+// Instructions, account order, signer and writable roles and lookup tables
+// are kept exactly as the server compiled them; only the blockhash and the
+// fee payer may be swapped. The transaction is then signed with signers.
+//
+// Signatures the server already made are kept while the message is
+// unchanged. Changing it would invalidate them, so that is refused unless
+// signers cover every required signature. The returned block height is zero
+// when the server's blockhash was kept.
+func Rewrite(ctx context.Context, client *rpc.Client, tx *solana.Transaction, opts RewriteOptions, signers ...solana.PrivateKey) (uint64, error) {
+	message := &tx.Message
+	required := int(message.Header.NumRequiredSignatures)
+	if required == 0 || len(message.AccountKeys) < required {
+		return 0, fmt.Errorf("неожиданный заголовок транзакции")
+	}
+
+	keys := make(map[solana.PublicKey]*solana.PrivateKey, len(signers))
+	for i := range signers {
+		keys[signers[i].PublicKey()] = &signers[i]
+	}
+
+	changed := false
+	if !opts.FeePayer.IsZero() && !message.AccountKeys[0].Equals(opts.FeePayer) {
+		for _, key := range message.AccountKeys[1:] {
+			if key.Equals(opts.FeePayer) {
+				return 0, fmt.Errorf("%s уже участвует в транзакции, нельзя сделать его плательщиком", opts.FeePayer)
+			}
+		}
+		message.AccountKeys[0] = opts.FeePayer
+		changed = true
+	}
+
+	var lastValidBlockHeight uint64
+	if opts.RefreshBlockhash {
+		recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return 0, fmt.Errorf("error getting latest blockhash: %v", err)
+		}
+		message.RecentBlockhash = recent.Value.Blockhash
+		lastValidBlockHeight = recent.Value.LastValidBlockHeight
+		changed = true
+	}
+
+	signerKeys := message.AccountKeys[:required]
+	for key := range keys {
+		if !signerKeys.Contains(key) {
+			return 0, fmt.Errorf("transaction does not require signature from %s", key)
+		}
+	}
+
+	signatures := make([]solana.Signature, required)
+	if changed {
+		for _, key := range signerKeys {
+			if keys[key] == nil {
+				return 0, fmt.Errorf("нельзя изменить транзакцию, подписанную %s", key)
+			}
+		}
+	} else {
+		copy(signatures, tx.Signatures)
+	}
+	tx.Signatures = signatures
+
+	if _, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		return keys[key]
+	}); err != nil {
+		return 0, fmt.Errorf("error signing transaction: %v", err)
+	}
+
+	for i, signature := range tx.Signatures {
+		if signature.IsZero() {
+			return 0, fmt.Errorf("транзакции не хватает подписи %s", signerKeys[i])
+		}
+	}
+
+	return lastValidBlockHeight, nil
+}
@@ -0,0 +1,169 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// serverTransaction is a transaction built and partly signed by an API: the
+// server pays the fees and the user moves lamports, so both have to sign.
+func serverTransaction(t *testing.T, server, user solana.PrivateKey) *solana.Transaction {
+	t.Helper()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1, user.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+			system.NewTransferInstruction(2, user.PublicKey(), server.PublicKey()).Build(),
+		},
+		solana.Hash{7},
+		solana.TransactionPayer(server.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(server.PublicKey()) {
+			return &server
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestRewrite(t *testing.T) {
+	server := solana.NewWallet().PrivateKey
+	user := solana.NewWallet().PrivateKey
+	payer := solana.NewWallet().PrivateKey
+	blockhash := solana.Hash{9}
+
+	tests := []struct {
+		name          string
+		opts          RewriteOptions
+		signers       []solana.PrivateKey
+		wantPayer     solana.PublicKey
+		wantBlockhash solana.Hash
+		wantHeight    uint64
+		wantErr       string
+	}{
+		{
+			name:          "server signature kept",
+			signers:       []solana.PrivateKey{user},
+			wantPayer:     server.PublicKey(),
+			wantBlockhash: solana.Hash{7},
+		},
+		{
+			name:          "different fee payer",
+			opts:          RewriteOptions{FeePayer: payer.PublicKey()},
+			signers:       []solana.PrivateKey{payer, user},
+			wantPayer:     payer.PublicKey(),
+			wantBlockhash: solana.Hash{7},
+		},
+		{
+			name:          "same fee payer",
+			opts:          RewriteOptions{FeePayer: server.PublicKey()},
+			signers:       []solana.PrivateKey{user},
+			wantPayer:     server.PublicKey(),
+			wantBlockhash: solana.Hash{7},
+		},
+		{
+			name:          "fresh blockhash",
+			opts:          RewriteOptions{RefreshBlockhash: true},
+			signers:       []solana.PrivateKey{server, user},
+			wantPayer:     server.PublicKey(),
+			wantBlockhash: blockhash,
+			wantHeight:    150,
+		},
+		{
+			name:    "fee payer without the other signer",
+			opts:    RewriteOptions{FeePayer: payer.PublicKey()},
+			signers: []solana.PrivateKey{payer},
+			wantErr: "нельзя изменить транзакцию, подписанную " + user.PublicKey().String(),
+		},
+		{
+			name:    "fee payer already in the transaction",
+			opts:    RewriteOptions{FeePayer: user.PublicKey()},
+			signers: []solana.PrivateKey{user},
+			wantErr: "уже участвует в транзакции",
+		},
+		{
+			name:    "signer not in the transaction",
+			signers: []solana.PrivateKey{payer},
+			wantErr: "transaction does not require signature",
+		},
+		{
+			name:    "missing user signature",
+			wantErr: "транзакции не хватает подписи " + user.PublicKey().String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rpcServer(t, func(method string, params []json.RawMessage, call int) (interface{}, error) {
+				if method != "getLatestBlockhash" {
+					return nil, fmt.Errorf("unexpected method %s", method)
+				}
+				return map[string]interface{}{
+					"context": map[string]uint64{"slot": 10},
+					"value":   map[string]interface{}{"blockhash": blockhash.String(), "lastValidBlockHeight": 150},
+				}, nil
+			})
+
+			tx := serverTransaction(t, server, user)
+			serverSignature := tx.Signatures[0]
+			original, err := tx.Message.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			height, err := Rewrite(context.Background(), client, tx, tt.opts, tt.signers...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if height != tt.wantHeight {
+				t.Errorf("last valid block height = %d, want %d", height, tt.wantHeight)
+			}
+			if !tx.Message.AccountKeys[0].Equals(tt.wantPayer) {
+				t.Errorf("fee payer = %s, want %s", tx.Message.AccountKeys[0], tt.wantPayer)
+			}
+			if tx.Message.RecentBlockhash != tt.wantBlockhash {
+				t.Errorf("blockhash = %s, want %s", tx.Message.RecentBlockhash, tt.wantBlockhash)
+			}
+			if err := tx.VerifySignatures(); err != nil {
+				t.Errorf("signatures do not verify: %v", err)
+			}
+			if tt.wantPayer.Equals(server.PublicKey()) && tt.wantBlockhash == (solana.Hash{7}) && tx.Signatures[0] != serverSignature {
+				t.Errorf("server signature was replaced although the message did not change")
+			}
+
+			// apart from the fee payer and the blockhash the message is
+			// exactly what the server compiled
+			message := tx.Message
+			message.AccountKeys = append(solana.PublicKeySlice{server.PublicKey()}, message.AccountKeys[1:]...)
+			message.RecentBlockhash = solana.Hash{7}
+			rewritten, err := message.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(rewritten, original) {
+				t.Errorf("message changed beyond the fee payer and blockhash")
+			}
+		})
+	}
+}
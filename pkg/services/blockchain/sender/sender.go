@@ -0,0 +1,322 @@
+package sender
+
+import (
+	"context"
+	"eclipse/constants"
+	"eclipse/internal/logger"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusConfirmed
+	StatusFailed
+	StatusRejected
+	StatusExpired
+	StatusDropped
+	StatusSimulated
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusConfirmed:
+		return "confirmed"
+	case StatusFailed:
+		return "failed"
+	case StatusRejected:
+		return "rejected"
+	case StatusExpired:
+		return "expired"
+	case StatusDropped:
+		return "dropped"
+	case StatusSimulated:
+		return "simulated"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrTransactionFailed   = errors.New("transaction failed")
+	ErrTransactionRejected = errors.New("transaction rejected by rpc")
+	ErrTransactionExpired  = errors.New("transaction blockhash expired before confirmation")
+	ErrTransactionDropped  = errors.New("transaction was processed but dropped before confirmation")
+	ErrTransactionPending  = errors.New("transaction status is unknown")
+)
+
+type Options struct {
+	SkipPreflight       bool
+	PreflightCommitment rpc.CommitmentType
+	// LastValidBlockHeight comes from GetLatestBlockhash. Server-built
+	// transactions don't carry it, so when it is zero expiry is detected
+	// through isBlockhashValid instead.
+	LastValidBlockHeight uint64
+	PollInterval         time.Duration
+	RebroadcastInterval  time.Duration
+	// ConfirmTimeout bounds how long a broadcast transaction is followed.
+	// Past it the outcome is reported as ErrTransactionPending.
+	ConfirmTimeout time.Duration
+	Tracker        *Tracker
+}
+
+type Result struct {
+	Signature solana.Signature
+	Status    Status
+	Slot      uint64
+}
+
+func DefaultOptions() Options {
+	return Options{
+		SkipPreflight:       false,
+		PreflightCommitment: rpc.CommitmentConfirmed,
+		PollInterval:        2 * time.Second,
+		RebroadcastInterval: 4 * time.Second,
+		ConfirmTimeout:      3 * time.Minute,
+	}
+}
+
+// SendAndConfirm broadcasts tx and waits for it to be confirmed. When a
+// Tracker is set, earlier attempts are resolved first so that a swap which
+// landed late is reported instead of being executed a second time.
+//
+// A cancelled ctx prevents the broadcast, but once tx is on the wire it is
+// followed until it confirms or its blockhash expires, so shutdown never
+// leaves a swap in an unknown state.
+func SendAndConfirm(ctx context.Context, client *rpc.Client, tx *solana.Transaction, opts Options) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return &Result{}, err
+	}
+
+	if IsDryRun(ctx) {
+		return simulateOnly(ctx, client, tx)
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	if opts.Tracker == nil {
+		return sendAndConfirm(ctx, client, tx, opts)
+	}
+
+	landed, err := opts.Tracker.Resolve(ctx)
+	if err != nil {
+		return &Result{}, err
+	}
+	if landed != nil {
+		return &Result{Signature: landed.Signature, Status: StatusConfirmed}, nil
+	}
+
+	result, sendErr := sendAndConfirm(ctx, client, tx, opts)
+	opts.Tracker.record(tx, opts, result)
+	if sendErr == nil {
+		return result, nil
+	}
+
+	landed, err = opts.Tracker.Resolve(ctx)
+	if err != nil {
+		return result, err
+	}
+	if landed != nil {
+		return &Result{Signature: landed.Signature, Status: StatusConfirmed}, nil
+	}
+
+	return result, sendErr
+}
+
+func simulateOnly(ctx context.Context, client *rpc.Client, tx *solana.Transaction) (*Result, error) {
+	result := &Result{Status: StatusSimulated}
+	if len(tx.Signatures) > 0 {
+		result.Signature = tx.Signatures[0]
+	}
+
+	sim, err := Simulate(ctx, client, tx)
+	if sim != nil {
+		sim.Log()
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		return result, err
+	}
+
+	logger.Success("[dry-run] Симуляция успешна, транзакция не отправлена")
+	return result, nil
+}
+
+func sendAndConfirm(ctx context.Context, client *rpc.Client, tx *solana.Transaction, opts Options) (*Result, error) {
+	if len(tx.Signatures) == 0 {
+		return &Result{Status: StatusFailed}, fmt.Errorf("%w: transaction is not signed", ErrTransactionFailed)
+	}
+
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultOptions().PollInterval
+	}
+	if opts.RebroadcastInterval == 0 {
+		opts.RebroadcastInterval = DefaultOptions().RebroadcastInterval
+	}
+	if opts.ConfirmTimeout == 0 {
+		opts.ConfirmTimeout = DefaultOptions().ConfirmTimeout
+	}
+
+	result := &Result{Signature: tx.Signatures[0]}
+
+	retries := uint(0)
+	sendOpts := rpc.TransactionOpts{
+		SkipPreflight:       opts.SkipPreflight,
+		PreflightCommitment: opts.PreflightCommitment,
+		MaxRetries:          &retries,
+	}
+
+	// subscribe before the broadcast so a fast confirmation is not missed
+	notified, release := subscribe(ctx, result.Signature)
+	defer release()
+
+	if _, err := client.SendTransactionWithOpts(ctx, tx, sendOpts); err != nil {
+		var rpcErr *jsonrpc.RPCError
+		if errors.As(err, &rpcErr) {
+			result.Status = StatusRejected
+			return result, fmt.Errorf("%w: %v", ErrTransactionRejected, err)
+		}
+		return result, fmt.Errorf("%w: %v", ErrTransactionPending, err)
+	}
+
+	sig := result.Signature
+	seen := false
+	lastBroadcast := time.Now()
+	deadline := lastBroadcast.Add(opts.ConfirmTimeout)
+
+	for {
+		woken, err := wait(ctx, opts.PollInterval, notified)
+		if err != nil {
+			return result, err
+		}
+		if woken {
+			// the notification is acted on once, the websocket and http
+			// nodes may lag behind each other
+			notified = nil
+		}
+
+		status, err := getStatus(ctx, client, sig, false)
+		if err != nil {
+			logger.Info("Waiting for confirmation %s... (%v)", sig, err)
+		} else if status != nil {
+			result.Slot = status.Slot
+			if status.Err != nil {
+				result.Status = StatusFailed
+				return result, fmt.Errorf("%w: %v", ErrTransactionFailed, status.Err)
+			}
+			if isConfirmed(status) {
+				result.Status = StatusConfirmed
+				logger.Success("Transaction sent successfully: %s%s", constants.EclipseScan, sig)
+				return result, nil
+			}
+			seen = true
+		}
+
+		// RPC errors could otherwise keep the loop going forever
+		if time.Now().After(deadline) {
+			return result, fmt.Errorf("%w: статус %s неизвестен спустя %s", ErrTransactionPending, sig, opts.ConfirmTimeout)
+		}
+
+		expired, err := isExpired(ctx, client, tx, opts)
+		if err != nil {
+			logger.Info("Failed to check blockhash expiry for %s: %v", sig, err)
+			continue
+		}
+
+		if expired {
+			return finalize(ctx, client, result, seen)
+		}
+
+		if time.Since(lastBroadcast) >= opts.RebroadcastInterval {
+			sendOpts.SkipPreflight = true
+			if _, err := client.SendTransactionWithOpts(ctx, tx, sendOpts); err != nil {
+				logger.Debug("Rebroadcast of %s rejected: %v", sig, err)
+			}
+			lastBroadcast = time.Now()
+		}
+	}
+}
+
+func finalize(ctx context.Context, client *rpc.Client, result *Result, seen bool) (*Result, error) {
+	status, err := getStatus(ctx, client, result.Signature, true)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", ErrTransactionPending, err)
+	}
+
+	if status != nil {
+		result.Slot = status.Slot
+		if status.Err != nil {
+			result.Status = StatusFailed
+			return result, fmt.Errorf("%w: %v", ErrTransactionFailed, status.Err)
+		}
+		if isConfirmed(status) {
+			result.Status = StatusConfirmed
+			logger.Success("Transaction sent successfully: %s%s", constants.EclipseScan, result.Signature)
+			return result, nil
+		}
+	}
+
+	if seen {
+		result.Status = StatusDropped
+		return result, fmt.Errorf("%w: %s", ErrTransactionDropped, result.Signature)
+	}
+
+	result.Status = StatusExpired
+	return result, fmt.Errorf("%w: %s", ErrTransactionExpired, result.Signature)
+}
+
+func getStatus(ctx context.Context, client *rpc.Client, sig solana.Signature, searchHistory bool) (*rpc.SignatureStatusesResult, error) {
+	statuses, err := client.GetSignatureStatuses(ctx, searchHistory, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statuses.Value) == 0 {
+		return nil, nil
+	}
+
+	return statuses.Value[0], nil
+}
+
+func isConfirmed(status *rpc.SignatureStatusesResult) bool {
+	return status.ConfirmationStatus == rpc.ConfirmationStatusConfirmed ||
+		status.ConfirmationStatus == rpc.ConfirmationStatusFinalized
+}
+
+func isExpired(ctx context.Context, client *rpc.Client, tx *solana.Transaction, opts Options) (bool, error) {
+	if opts.LastValidBlockHeight > 0 {
+		height, err := client.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+		if err != nil {
+			return false, err
+		}
+		return height > opts.LastValidBlockHeight, nil
+	}
+
+	valid, err := client.IsBlockhashValid(ctx, tx.Message.RecentBlockhash, rpc.CommitmentProcessed)
+	if err != nil {
+		return false, err
+	}
+
+	return !valid.Value, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
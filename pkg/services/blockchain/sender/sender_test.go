@@ -0,0 +1,320 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// rpcHandler answers one JSON-RPC request. call counts the requests of
+// method so far, starting at 1. A returned error is sent as a JSON-RPC error.
+type rpcHandler func(method string, params []json.RawMessage, call int) (interface{}, error)
+
+// rpcServer serves handle and returns a client for it together with the
+// number of requests made per method.
+func rpcServer(t *testing.T, handle rpcHandler) (*rpc.Client, func(method string) int) {
+	t.Helper()
+
+	var mutex sync.Mutex
+	calls := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		mutex.Lock()
+		calls[req.Method]++
+		call := calls[req.Method]
+		mutex.Unlock()
+
+		result, err := handle(req.Method, req.Params, call)
+		if err != nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32002,"message":%q}}`, req.ID, err)
+			return
+		}
+
+		body, _ := json.Marshal(result)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return rpc.New(server.URL), func(method string) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return calls[method]
+	}
+}
+
+func landed(confirmation rpc.ConfirmationStatusType) *rpc.SignatureStatusesResult {
+	return &rpc.SignatureStatusesResult{Slot: 10, ConfirmationStatus: confirmation}
+}
+
+func failedOnChain() *rpc.SignatureStatusesResult {
+	return &rpc.SignatureStatusesResult{
+		Slot:               10,
+		ConfirmationStatus: rpc.ConfirmationStatusConfirmed,
+		Err:                map[string]interface{}{"InstructionError": []interface{}{0, map[string]int{"Custom": 1}}},
+	}
+}
+
+// statusesResult is a getSignatureStatuses result, a nil status stands for a
+// signature unknown to the node.
+func statusesResult(statuses ...*rpc.SignatureStatusesResult) interface{} {
+	return map[string]interface{}{
+		"context": map[string]uint64{"slot": 10},
+		"value":   statuses,
+	}
+}
+
+// signedTransfer is a transfer signed by a fresh fee payer.
+func signedTransfer(t *testing.T) *solana.Transaction {
+	t.Helper()
+
+	payer := solana.NewWallet().PrivateKey
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build()},
+		solana.Hash(solana.NewWallet().PublicKey()),
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &payer }); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// testOptions polls without delay and treats the transaction as valid up to
+// block height 100.
+func testOptions() Options {
+	return Options{
+		PreflightCommitment:  rpc.CommitmentConfirmed,
+		LastValidBlockHeight: 100,
+		PollInterval:         time.Millisecond,
+		RebroadcastInterval:  time.Millisecond,
+		ConfirmTimeout:       time.Second,
+	}
+}
+
+// chainHandler accepts sendTransaction unless sendErr is set, answers
+// getBlockHeight with height and getSignatureStatuses with status(call).
+func chainHandler(sendErr error, height uint64, status func(call int) *rpc.SignatureStatusesResult) rpcHandler {
+	return func(method string, params []json.RawMessage, call int) (interface{}, error) {
+		switch method {
+		case "sendTransaction":
+			if sendErr != nil {
+				return nil, sendErr
+			}
+			return solana.Signature{}.String(), nil
+		case "getBlockHeight":
+			return height, nil
+		case "getSignatureStatuses":
+			return statusesResult(status(call)), nil
+		}
+		return nil, fmt.Errorf("unexpected method %s", method)
+	}
+}
+
+func always(status *rpc.SignatureStatusesResult) func(int) *rpc.SignatureStatusesResult {
+	return func(int) *rpc.SignatureStatusesResult { return status }
+}
+
+func TestSendAndConfirm(t *testing.T) {
+	tests := []struct {
+		name       string
+		sendErr    error
+		height     uint64
+		status     func(call int) *rpc.SignatureStatusesResult
+		timeout    time.Duration
+		wantStatus Status
+		wantErr    error
+	}{
+		{
+			name:       "confirmed",
+			height:     50,
+			status:     always(landed(rpc.ConfirmationStatusConfirmed)),
+			wantStatus: StatusConfirmed,
+		},
+		{
+			name:   "landed after a few polls",
+			height: 50,
+			status: func(call int) *rpc.SignatureStatusesResult {
+				if call < 3 {
+					return nil
+				}
+				return landed(rpc.ConfirmationStatusFinalized)
+			},
+			wantStatus: StatusConfirmed,
+		},
+		{
+			name:       "failed on chain",
+			height:     50,
+			status:     always(failedOnChain()),
+			wantStatus: StatusFailed,
+			wantErr:    ErrTransactionFailed,
+		},
+		{
+			name:       "expired",
+			height:     101,
+			status:     always(nil),
+			wantStatus: StatusExpired,
+			wantErr:    ErrTransactionExpired,
+		},
+		{
+			name:       "dropped",
+			height:     101,
+			status:     always(landed(rpc.ConfirmationStatusProcessed)),
+			wantStatus: StatusDropped,
+			wantErr:    ErrTransactionDropped,
+		},
+		{
+			name:       "rejected",
+			sendErr:    errors.New("Blockhash not found"),
+			height:     50,
+			status:     always(nil),
+			wantStatus: StatusRejected,
+			wantErr:    ErrTransactionRejected,
+		},
+		{
+			name:       "unknown past the timeout",
+			height:     50,
+			status:     always(nil),
+			timeout:    20 * time.Millisecond,
+			wantStatus: StatusPending,
+			wantErr:    ErrTransactionPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rpcServer(t, chainHandler(tt.sendErr, tt.height, tt.status))
+			tx := signedTransfer(t)
+
+			opts := testOptions()
+			if tt.timeout > 0 {
+				opts.ConfirmTimeout = tt.timeout
+			}
+
+			result, err := SendAndConfirm(context.Background(), client, tx, opts)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.Signature != tx.Signatures[0] {
+				t.Errorf("signature = %s, want %s", result.Signature, tx.Signatures[0])
+			}
+		})
+	}
+}
+
+func TestSendAndConfirmTracker(t *testing.T) {
+	earlier := Attempt{Signature: solana.Signature{1}, LastValidBlockHeight: 100}
+
+	tests := []struct {
+		name       string
+		earlier    *rpc.SignatureStatusesResult
+		height     uint64
+		wantSends  int
+		wantLanded bool
+	}{
+		{
+			name:       "earlier attempt landed late",
+			earlier:    landed(rpc.ConfirmationStatusConfirmed),
+			height:     101,
+			wantSends:  0,
+			wantLanded: true,
+		},
+		{
+			name:      "earlier attempt expired",
+			earlier:   nil,
+			height:    101,
+			wantSends: 1,
+		},
+		{
+			name:      "earlier attempt failed",
+			earlier:   failedOnChain(),
+			height:    101,
+			wantSends: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := signedTransfer(t)
+
+			client, calls := rpcServer(t, func(method string, params []json.RawMessage, call int) (interface{}, error) {
+				switch method {
+				case "sendTransaction":
+					return tx.Signatures[0].String(), nil
+				case "getBlockHeight":
+					return tt.height, nil
+				case "getSignatureStatuses":
+					var signatures []solana.Signature
+					if err := json.Unmarshal(params[0], &signatures); err != nil {
+						return nil, err
+					}
+					if signatures[0] == earlier.Signature {
+						return statusesResult(tt.earlier), nil
+					}
+					return statusesResult(landed(rpc.ConfirmationStatusConfirmed)), nil
+				}
+				return nil, fmt.Errorf("unexpected method %s", method)
+			})
+
+			opts := testOptions()
+			opts.Tracker = RestoreTracker(client, []Attempt{earlier})
+			opts.Tracker.pollInterval = time.Millisecond
+
+			result, err := SendAndConfirm(context.Background(), client, tx, opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := calls("sendTransaction"); got != tt.wantSends {
+				t.Errorf("sent %d times, want %d", got, tt.wantSends)
+			}
+			want := tx.Signatures[0]
+			if tt.wantLanded {
+				want = earlier.Signature
+			}
+			if result.Status != StatusConfirmed || result.Signature != want {
+				t.Errorf("result = %s %s, want confirmed %s", result.Status, result.Signature, want)
+			}
+		})
+	}
+}
+
+func TestSendAndConfirmWebsocketUnavailable(t *testing.T) {
+	client, _ := rpcServer(t, chainHandler(nil, 50, always(landed(rpc.ConfirmationStatusConfirmed))))
+	tx := signedTransfer(t)
+
+	ctx := WithWebsocket(context.Background(), func() string { return "ws://127.0.0.1:1" })
+	result, err := SendAndConfirm(ctx, client, tx, testOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != StatusConfirmed {
+		t.Errorf("status = %s, want confirmed through polling", result.Status)
+	}
+}
@@ -0,0 +1,167 @@
+package sender
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var ErrSimulationFailed = errors.New("transaction simulation failed")
+
+type dryRunKey struct{}
+
+// WithDryRun marks ctx so that SendAndConfirm only simulates transactions.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+type BalanceDelta struct {
+	Account  solana.PublicKey
+	Lamports int64
+	Mint     solana.PublicKey
+	Tokens   int64
+	IsToken  bool
+}
+
+type Simulation struct {
+	UnitsConsumed uint64
+	Logs          []string
+	Deltas        []BalanceDelta
+}
+
+func Simulate(ctx context.Context, client *rpc.Client, tx *solana.Transaction) (*Simulation, error) {
+	writable := writableKeys(tx)
+
+	before, err := client.GetMultipleAccountsWithOpts(ctx, writable, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: rpc.CommitmentProcessed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounts before simulation: %v", err)
+	}
+
+	res, err := client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment:             rpc.CommitmentProcessed,
+		ReplaceRecentBlockhash: true,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: writable,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSimulationFailed, err)
+	}
+
+	sim := &Simulation{Logs: res.Value.Logs}
+	if res.Value.UnitsConsumed != nil {
+		sim.UnitsConsumed = *res.Value.UnitsConsumed
+	}
+
+	if res.Value.Err != nil {
+		return sim, fmt.Errorf("%w: %v", ErrSimulationFailed, res.Value.Err)
+	}
+
+	for i, key := range writable {
+		var pre, post *rpc.Account
+		if i < len(before.Value) {
+			pre = before.Value[i]
+		}
+		if i < len(res.Value.Accounts) {
+			post = res.Value.Accounts[i]
+		}
+
+		delta := BalanceDelta{Account: key, Lamports: int64(lamports(post)) - int64(lamports(pre))}
+
+		preMint, preAmount, preOk := tokenAmount(pre)
+		postMint, postAmount, postOk := tokenAmount(post)
+		if preOk || postOk {
+			delta.IsToken = true
+			delta.Mint = postMint
+			if !postOk {
+				delta.Mint = preMint
+			}
+			delta.Tokens = int64(postAmount) - int64(preAmount)
+		}
+
+		if delta.Lamports != 0 || delta.Tokens != 0 {
+			sim.Deltas = append(sim.Deltas, delta)
+		}
+	}
+
+	return sim, nil
+}
+
+// SimulateInstructions simulates instructions as an unsigned transaction paid
+// by payer, which is enough to price a swap before it is built for real.
+func SimulateInstructions(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, payer solana.PublicKey, tables LookupTables) (*Simulation, error) {
+	tx, err := NewTransaction(instructions, solana.Hash{}, payer, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	return Simulate(ctx, client, tx)
+}
+
+// TokenDelta returns how many tokens account received in the simulation.
+func (s *Simulation) TokenDelta(account solana.PublicKey) int64 {
+	for _, d := range s.Deltas {
+		if d.IsToken && d.Account.Equals(account) {
+			return d.Tokens
+		}
+	}
+	return 0
+}
+
+func (s *Simulation) Log() {
+	logger.Info("[dry-run] Compute units: %d", s.UnitsConsumed)
+
+	for _, line := range s.Logs {
+		logger.Info("[dry-run]   %s", line)
+	}
+
+	if len(s.Deltas) == 0 {
+		logger.Info("[dry-run] Балансы не изменились")
+	}
+	for _, d := range s.Deltas {
+		if d.IsToken {
+			logger.Info("[dry-run] %s: %+d lamports, %+d токенов (mint %s)", d.Account, d.Lamports, d.Tokens, d.Mint)
+			continue
+		}
+		logger.Info("[dry-run] %s: %+.9f ETH", d.Account, float64(d.Lamports)/float64(solana.LAMPORTS_PER_SOL))
+	}
+}
+
+func lamports(account *rpc.Account) uint64 {
+	if account == nil {
+		return 0
+	}
+	return account.Lamports
+}
+
+// tokenAmount reads mint and amount from an SPL or Token-2022 token account,
+// both share the same layout for the first 72 bytes.
+func tokenAmount(account *rpc.Account) (solana.PublicKey, uint64, bool) {
+	if account == nil || account.Data == nil {
+		return solana.PublicKey{}, 0, false
+	}
+	if !account.Owner.Equals(solana.TokenProgramID) && !account.Owner.Equals(solana.Token2022ProgramID) {
+		return solana.PublicKey{}, 0, false
+	}
+
+	data := account.Data.GetBinary()
+	if len(data) < 165 {
+		return solana.PublicKey{}, 0, false
+	}
+
+	return solana.PublicKeyFromBytes(data[0:32]), binary.LittleEndian.Uint64(data[64:72]), true
+}
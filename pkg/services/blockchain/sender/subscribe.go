@@ -0,0 +1,83 @@
+package sender
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const connectTimeout = 5 * time.Second
+
+type websocketKey struct{}
+
+// WithWebsocket makes SendAndConfirm subscribe to the signature on the
+// websocket endpoint returned by endpoint, so a confirmation is noticed as
+// soon as the node reports it. Polling keeps running as the fallback.
+func WithWebsocket(ctx context.Context, endpoint func() string) context.Context {
+	return context.WithValue(ctx, websocketKey{}, endpoint)
+}
+
+// subscribe returns a channel closed once the node reports sig at confirmed
+// commitment, and a function releasing the subscription. The channel is nil
+// when no websocket is configured or it cannot be reached.
+func subscribe(ctx context.Context, sig solana.Signature) (<-chan struct{}, func()) {
+	endpoint, _ := ctx.Value(websocketKey{}).(func() string)
+	if endpoint == nil {
+		return nil, func() {}
+	}
+	url := endpoint()
+	if url == "" {
+		return nil, func() {}
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	client, err := ws.Connect(connectCtx, url)
+	if err != nil {
+		logger.Debug("Websocket %s недоступен, статус %s проверяется опросом: %v", url, sig, err)
+		return nil, func() {}
+	}
+
+	sub, err := client.SignatureSubscribe(sig, rpc.CommitmentConfirmed)
+	if err != nil {
+		client.Close()
+		logger.Debug("Не удалось подписаться на %s, статус проверяется опросом: %v", sig, err)
+		return nil, func() {}
+	}
+
+	recvCtx, stop := context.WithCancel(ctx)
+	notified := make(chan struct{})
+	go func() {
+		if _, err := sub.Recv(recvCtx); err == nil {
+			close(notified)
+		}
+	}()
+
+	// closing the connection drops the subscription, Unsubscribe could race
+	// with a notification being delivered
+	return notified, func() {
+		stop()
+		client.Close()
+	}
+}
+
+// wait sleeps for d or until notified is closed, whichever comes first, and
+// reports whether the notification cut the sleep short.
+func wait(ctx context.Context, d time.Duration, notified <-chan struct{}) (bool, error) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-timer.C:
+		return false, nil
+	case <-notified:
+		return true, nil
+	}
+}
@@ -0,0 +1,221 @@
+package sender
+
+import (
+	"context"
+	"eclipse/internal/logger"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type Attempt struct {
+	Signature            solana.Signature `json:"signature"`
+	Blockhash            solana.Hash      `json:"blockhash"`
+	LastValidBlockHeight uint64           `json:"last_valid_block_height"`
+	Status               Status           `json:"-"`
+}
+
+// PendingError lists the attempts that may still land. It matches
+// ErrTransactionPending, and the attempts can be handed to RestoreTracker
+// to resolve them later, e.g. when an interrupted run is resumed.
+type PendingError struct {
+	Attempts []Attempt
+}
+
+func (e *PendingError) Error() string {
+	signatures := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		signatures[i] = a.Signature.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrTransactionPending, strings.Join(signatures, ", "))
+}
+
+func (e *PendingError) Unwrap() error {
+	return ErrTransactionPending
+}
+
+// Tracker remembers every signature a module has broadcast so that a retry
+// is only made once all earlier attempts are known not to have landed.
+type Tracker struct {
+	client         *rpc.Client
+	attempts       []*Attempt
+	pollInterval   time.Duration
+	resolveTimeout time.Duration
+	mutex          sync.Mutex
+}
+
+func NewTracker(client *rpc.Client) *Tracker {
+	return &Tracker{
+		client:         client,
+		pollInterval:   DefaultOptions().PollInterval,
+		resolveTimeout: DefaultOptions().ConfirmTimeout,
+	}
+}
+
+// RestoreTracker returns a tracker that follows attempts saved from a
+// PendingError, all of them treated as pending.
+func RestoreTracker(client *rpc.Client, attempts []Attempt) *Tracker {
+	t := NewTracker(client)
+	for _, a := range attempts {
+		a.Status = StatusPending
+		t.attempts = append(t.attempts, &a)
+	}
+	return t
+}
+
+func (t *Tracker) record(tx *solana.Transaction, opts Options, result *Result) {
+	if t == nil || result == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.attempts = append(t.attempts, &Attempt{
+		Signature:            result.Signature,
+		Blockhash:            tx.Message.RecentBlockhash,
+		LastValidBlockHeight: opts.LastValidBlockHeight,
+		Status:               result.Status,
+	})
+}
+
+func (t *Tracker) Attempts() []Attempt {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	attempts := make([]Attempt, 0, len(t.attempts))
+	for _, a := range t.attempts {
+		attempts = append(attempts, *a)
+	}
+	return attempts
+}
+
+// Resolve blocks until every recorded attempt has a final status. It returns
+// the attempt that landed, or nil when all of them provably did not. An
+// attempt still unresolved after resolveTimeout yields ErrTransactionPending.
+func (t *Tracker) Resolve(ctx context.Context) (*Attempt, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	deadline := time.Now().Add(t.resolveTimeout)
+	for {
+		pending := false
+
+		for _, a := range t.attempts {
+			if a.Status == StatusConfirmed {
+				return a, nil
+			}
+			if a.Status != StatusPending {
+				continue
+			}
+
+			if err := t.check(ctx, a); err != nil {
+				logger.Info("Не удалось проверить статус транзакции %s: %v", a.Signature, err)
+			}
+
+			switch a.Status {
+			case StatusConfirmed:
+				logger.Success("Предыдущая попытка %s подтвердилась, повтор не нужен", a.Signature)
+				return a, nil
+			case StatusPending:
+				pending = true
+			}
+		}
+
+		if !pending {
+			return nil, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, t.unresolved()
+		}
+
+		logger.Info("Жду истечения blockhash предыдущей попытки перед повтором")
+		if err := sleep(ctx, t.pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Unresolved returns ErrTransactionPending when an attempt may still land,
+// so the caller reports the module as unfinished instead of failed.
+func (t *Tracker) Unresolved() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.unresolved()
+}
+
+func (t *Tracker) unresolved() error {
+	var pending []Attempt
+	for _, a := range t.attempts {
+		if a.Status == StatusPending {
+			pending = append(pending, *a)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	return &PendingError{Attempts: pending}
+}
+
+func (t *Tracker) check(ctx context.Context, a *Attempt) error {
+	status, err := getStatus(ctx, t.client, a.Signature, true)
+	if err != nil {
+		return err
+	}
+
+	if status != nil {
+		if status.Err != nil {
+			a.Status = StatusFailed
+			return nil
+		}
+		if isConfirmed(status) {
+			a.Status = StatusConfirmed
+			return nil
+		}
+	}
+
+	tx := &solana.Transaction{Message: solana.Message{RecentBlockhash: a.Blockhash}}
+	expired, err := isExpired(ctx, t.client, tx, Options{LastValidBlockHeight: a.LastValidBlockHeight})
+	if err != nil {
+		return err
+	}
+	if !expired {
+		return nil
+	}
+
+	status, err = getStatus(ctx, t.client, a.Signature, true)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status == nil:
+		a.Status = StatusExpired
+	case status.Err != nil:
+		a.Status = StatusFailed
+	case isConfirmed(status):
+		a.Status = StatusConfirmed
+	default:
+		a.Status = StatusDropped
+	}
+
+	return nil
+}
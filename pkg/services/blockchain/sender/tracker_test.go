@@ -0,0 +1,134 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestTrackerResolve(t *testing.T) {
+	first := solana.Signature{1}
+	second := solana.Signature{2}
+
+	tests := []struct {
+		name         string
+		statuses     map[solana.Signature]*rpc.SignatureStatusesResult
+		height       uint64
+		wantLanded   solana.Signature
+		wantStatuses []Status
+		wantPending  []solana.Signature
+	}{
+		{
+			name:         "landed late",
+			statuses:     map[solana.Signature]*rpc.SignatureStatusesResult{second: landed(rpc.ConfirmationStatusConfirmed)},
+			height:       101,
+			wantLanded:   second,
+			wantStatuses: []Status{StatusExpired, StatusConfirmed},
+		},
+		{
+			name:         "expired",
+			height:       101,
+			wantStatuses: []Status{StatusExpired, StatusExpired},
+		},
+		{
+			name: "dropped and failed",
+			statuses: map[solana.Signature]*rpc.SignatureStatusesResult{
+				first:  landed(rpc.ConfirmationStatusProcessed),
+				second: failedOnChain(),
+			},
+			height:       101,
+			wantStatuses: []Status{StatusDropped, StatusFailed},
+		},
+		{
+			name:         "blockhash still valid",
+			statuses:     map[solana.Signature]*rpc.SignatureStatusesResult{first: failedOnChain()},
+			height:       50,
+			wantStatuses: []Status{StatusFailed, StatusPending},
+			wantPending:  []solana.Signature{second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rpcServer(t, func(method string, params []json.RawMessage, call int) (interface{}, error) {
+				switch method {
+				case "getBlockHeight":
+					return tt.height, nil
+				case "getSignatureStatuses":
+					var signatures []solana.Signature
+					if err := json.Unmarshal(params[0], &signatures); err != nil {
+						return nil, err
+					}
+					return statusesResult(tt.statuses[signatures[0]]), nil
+				}
+				return nil, fmt.Errorf("unexpected method %s", method)
+			})
+
+			tracker := RestoreTracker(client, []Attempt{
+				{Signature: first, LastValidBlockHeight: 100},
+				{Signature: second, LastValidBlockHeight: 100},
+			})
+			tracker.pollInterval = time.Millisecond
+			tracker.resolveTimeout = 20 * time.Millisecond
+
+			attempt, err := tracker.Resolve(context.Background())
+
+			if len(tt.wantPending) > 0 {
+				var pendingErr *PendingError
+				if !errors.As(err, &pendingErr) || !errors.Is(err, ErrTransactionPending) {
+					t.Fatalf("err = %v, want a PendingError", err)
+				}
+				if len(pendingErr.Attempts) != len(tt.wantPending) || pendingErr.Attempts[0].Signature != tt.wantPending[0] {
+					t.Errorf("pending = %v, want %v", pendingErr.Attempts, tt.wantPending)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantLanded.IsZero() {
+				if attempt != nil {
+					t.Errorf("landed = %s, want none", attempt.Signature)
+				}
+			} else if attempt == nil || attempt.Signature != tt.wantLanded {
+				t.Errorf("landed = %v, want %s", attempt, tt.wantLanded)
+			}
+
+			for i, a := range tracker.Attempts() {
+				if a.Status != tt.wantStatuses[i] {
+					t.Errorf("attempt %d status = %s, want %s", i, a.Status, tt.wantStatuses[i])
+				}
+			}
+		})
+	}
+}
+
+// TestRestoreTracker saves attempts the way the run checkpoint does.
+func TestRestoreTracker(t *testing.T) {
+	attempts := []Attempt{{
+		Signature:            solana.Signature{1},
+		Blockhash:            solana.Hash{2},
+		LastValidBlockHeight: 100,
+		Status:               StatusPending,
+	}}
+
+	data, err := json.Marshal(attempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []Attempt
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := RestoreTracker(nil, saved).Attempts()
+	if len(restored) != 1 || restored[0] != attempts[0] {
+		t.Errorf("restored = %+v, want %+v", restored, attempts)
+	}
+}
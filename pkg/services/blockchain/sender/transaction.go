@@ -0,0 +1,174 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MaxTransactionSize is the largest serialized transaction the cluster
+// accepts.
+const MaxTransactionSize = 1232
+
+// LookupTables maps an address lookup table to the addresses it holds.
+type LookupTables map[solana.PublicKey]solana.PublicKeySlice
+
+// Tables are only ever extended and indexes never move, so resolved contents
+// are cached for the whole run and shared by all accounts.
+var (
+	lookupTables   = make(LookupTables)
+	lookupTablesMu sync.RWMutex
+)
+
+// LoadLookupTables resolves the addresses held by the given lookup tables.
+func LoadLookupTables(ctx context.Context, client *rpc.Client, addresses []solana.PublicKey) (LookupTables, error) {
+	tables := make(LookupTables, len(addresses))
+	var missing []solana.PublicKey
+
+	lookupTablesMu.RLock()
+	for _, address := range addresses {
+		if table, ok := lookupTables[address]; ok {
+			tables[address] = table
+		} else {
+			missing = append(missing, address)
+		}
+	}
+	lookupTablesMu.RUnlock()
+
+	if len(missing) == 0 {
+		return tables, nil
+	}
+
+	res, err := client.GetMultipleAccountsWithOpts(ctx, missing, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить lookup таблицы: %v", err)
+	}
+
+	for i, address := range missing {
+		if i >= len(res.Value) || res.Value[i] == nil {
+			return nil, fmt.Errorf("lookup таблица %s не найдена", address)
+		}
+
+		state, err := addresslookuptable.DecodeAddressLookupTableState(res.Value[i].Data.GetBinary())
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать lookup таблицу %s: %v", address, err)
+		}
+		if !state.IsActive() {
+			return nil, fmt.Errorf("lookup таблица %s деактивирована", address)
+		}
+
+		tables[address] = state.Addresses
+	}
+
+	lookupTablesMu.Lock()
+	for _, address := range missing {
+		lookupTables[address] = tables[address]
+	}
+	lookupTablesMu.Unlock()
+
+	return tables, nil
+}
+
+// NewTransaction compiles instructions into an unsigned v0 transaction paid
+// by payer. Accounts held by tables are referenced through them, the rest
+// stay in the static account list.
+func NewTransaction(instructions []solana.Instruction, blockhash solana.Hash, payer solana.PublicKey, tables LookupTables) (*solana.Transaction, error) {
+	opts := []solana.TransactionOption{solana.TransactionPayer(payer)}
+	if len(tables) > 0 {
+		opts = append(opts, solana.TransactionAddressTables(tables))
+	}
+
+	tx, err := solana.NewTransaction(instructions, blockhash, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction: %v", err)
+	}
+	tx.Message.SetVersion(solana.MessageVersionV0)
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	size, err := TransactionSize(tx)
+	if err != nil {
+		return nil, err
+	}
+	if size > MaxTransactionSize {
+		return nil, fmt.Errorf("транзакция занимает %d байт, максимум %d", size, MaxTransactionSize)
+	}
+
+	return tx, nil
+}
+
+// BuildTransaction compiles instructions against a fresh blockhash and signs
+// the result with payer and the extra signers. It returns the last block
+// height the transaction can land at.
+func BuildTransaction(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, payer solana.PrivateKey, tables LookupTables, signers ...solana.PrivateKey) (*solana.Transaction, uint64, error) {
+	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting latest blockhash: %v", err)
+	}
+
+	tx, err := NewTransaction(instructions, recent.Value.Blockhash, payer.PublicKey(), tables)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keys := append([]solana.PrivateKey{payer}, signers...)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		for i := range keys {
+			if keys[i].PublicKey().Equals(key) {
+				return &keys[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error signing transaction: %v", err)
+	}
+
+	return tx, recent.Value.LastValidBlockHeight, nil
+}
+
+// TransactionSize returns the size of tx on the wire, counting a slot for
+// every signature in tx.Signatures.
+func TransactionSize(tx *solana.Transaction) (int, error) {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("error encoding transaction: %v", err)
+	}
+
+	// compact-u16 signature count, one byte below 128 signatures
+	return 1 + len(tx.Signatures)*solana.SignatureLength + len(message), nil
+}
+
+// writableKeys returns every writable account of tx, including the ones
+// loaded from lookup tables when their contents are known. Message.Writable
+// only sees lookup accounts after ResolveLookups, which would modify tx.
+func writableKeys(tx *solana.Transaction) []solana.PublicKey {
+	var writable []solana.PublicKey
+	for _, key := range tx.Message.AccountKeys {
+		if ok, err := tx.Message.IsWritable(key); err == nil && ok {
+			writable = append(writable, key)
+		}
+	}
+
+	if tx.Message.IsResolved() {
+		return writable
+	}
+
+	tables := tx.Message.GetAddressTables()
+	for _, lookup := range tx.Message.AddressTableLookups {
+		table := tables[lookup.AccountKey]
+		for _, index := range lookup.WritableIndexes {
+			if int(index) < len(table) {
+				writable = append(writable, table[index])
+			}
+		}
+	}
+
+	return writable
+}
@@ -2,148 +2,138 @@
 
 import (
 	"context"
-	"eclipse/constants"
-	"eclipse/internal/logger"
+	"eclipse/configs"
 	"eclipse/internal/token"
+	"eclipse/pkg/services/blockchain/fees"
+	"eclipse/pkg/services/blockchain/sender"
 	"encoding/base64"
 	"fmt"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
-	"time"
 )
 
-func ExecuteSwapFromInstructions(ctx context.Context, client *rpc.Client, encodedTx string, feePayer solana.PrivateKey) (solana.Signature, error) {
-	txBytes, err := base64.StdEncoding.DecodeString(encodedTx)
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("failed to decode base64: %v", err)
-	}
-
-	originalTx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(txBytes))
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("failed to decode transaction: %v", err)
+// defaultComputeUnitPrice is the priority fee requested from Solar when no
+// fee policy is configured.
+const defaultComputeUnitPrice = 200_000
+
+// ComputeUnitPrice returns the priority fee Solar should build the swap
+// with, priced from the pools and accounts of its route. The transaction is
+// signed as the server built it, so the fee policy can only price it, not
+// rewrite its budget instructions.
+func ComputeUnitPrice(ctx context.Context, policy *fees.Policy, route []RoutePlan) uint64 {
+	if policy == nil {
+		return defaultComputeUnitPrice
 	}
+	return policy.Price(ctx, routeAccounts(route))
+}
 
-	instructions := make([]solana.Instruction, 0)
-
-	for i, inst := range originalTx.Message.Instructions {
-		accounts := make(solana.AccountMetaSlice, 0)
-		programID := originalTx.Message.AccountKeys[inst.ProgramIDIndex]
-
-		if i == 0 && programID.Equals(token.ATA_PROGRAM_ID) {
-			accounts = append(accounts,
-				solana.NewAccountMeta(originalTx.Message.AccountKeys[inst.Accounts[0]], true, true),
-				solana.NewAccountMeta(originalTx.Message.AccountKeys[inst.Accounts[1]], false, true),
-				solana.NewAccountMeta(originalTx.Message.AccountKeys[inst.Accounts[2]], true, false),
-				solana.NewAccountMeta(originalTx.Message.AccountKeys[inst.Accounts[3]], false, false),
-				solana.NewAccountMeta(originalTx.Message.AccountKeys[inst.Accounts[4]], false, false),
-				solana.NewAccountMeta(originalTx.Message.AccountKeys[inst.Accounts[5]], false, false),
-			)
-		} else {
-			for _, accIdx := range inst.Accounts {
-				acc := originalTx.Message.AccountKeys[accIdx]
-
-				accIdxInt := int(accIdx)
-				isWritable := false
-				isSigner := false
-
-				if accIdxInt < int(originalTx.Message.Header.NumRequiredSignatures-originalTx.Message.Header.NumReadonlySignedAccounts) {
-					isWritable = true
-				} else if accIdxInt >= int(originalTx.Message.Header.NumRequiredSignatures) &&
-					accIdxInt < len(originalTx.Message.AccountKeys)-int(originalTx.Message.Header.NumReadonlyUnsignedAccounts) {
-					isWritable = true
-				}
-
-				if accIdxInt < int(originalTx.Message.Header.NumRequiredSignatures) {
-					isSigner = true
-				}
-
-				accounts = append(accounts, solana.NewAccountMeta(acc, isSigner, isWritable))
+// routeAccounts are the accounts the swap writes to, every pool of the
+// route and the accounts it passes on.
+func routeAccounts(route []RoutePlan) []solana.PublicKey {
+	var accounts []solana.PublicKey
+	for _, step := range route {
+		for _, address := range append([]string{step.PoolID}, step.RemainingAccounts...) {
+			if account, err := solana.PublicKeyFromBase58(address); err == nil {
+				accounts = append(accounts, account)
 			}
 		}
-
-		newInstruction := solana.NewInstruction(
-			programID,
-			accounts,
-			inst.Data,
-		)
-
-		instructions = append(instructions, newInstruction)
 	}
+	return accounts
+}
 
-	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+func ExecuteSwapFromInstructions(ctx context.Context, client *rpc.Client, encodedTx string, feePayer solana.PrivateKey, tracker *sender.Tracker) (solana.Signature, error) {
+	tx, err := decodeTransaction(encodedTx)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error getting latest blockhash: %v", err)
+		return solana.Signature{}, err
 	}
 
-	tx, err := solana.NewTransaction(
-		instructions,
-		recent.Value.Blockhash,
-		solana.TransactionPayer(feePayer.PublicKey()),
-	)
+	lastValidBlockHeight, err := sender.Rewrite(ctx, client, tx, sender.RewriteOptions{
+		FeePayer:         feePayer.PublicKey(),
+		RefreshBlockhash: true,
+	}, feePayer)
 	if err != nil {
-		logger.Error("error creating transaction: %v", err)
+		return solana.Signature{}, err
 	}
 
-	tx.Message.SetVersion(0)
+	opts := sender.DefaultOptions()
+	opts.PreflightCommitment = rpc.CommitmentFinalized
+	opts.LastValidBlockHeight = lastValidBlockHeight
+	opts.Tracker = tracker
 
-	for i := range tx.Message.AccountKeys {
-		if tx.Message.AccountKeys[i].Equals(feePayer.PublicKey()) {
-			tx.Message.Header.NumRequiredSignatures = 1
-			tx.Message.Header.NumReadonlySignedAccounts = 0
-			break
-		}
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		return result.Signature, fmt.Errorf("error sending transaction: %w", err)
 	}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(feePayer.PublicKey()) {
-			return &feePayer
-		}
-		return nil
-	})
+	return result.Signature, nil
+}
+
+func decodeTransaction(encodedTx string) (*solana.Transaction, error) {
+	txBytes, err := base64.StdEncoding.DecodeString(encodedTx)
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error signing transaction: %v", err)
+		return nil, fmt.Errorf("failed to decode base64: %v", err)
 	}
 
-	retrie := uint(5)
-	sig, err := client.SendTransactionWithOpts(ctx, tx,
-		rpc.TransactionOpts{
-			SkipPreflight:       false,
-			PreflightCommitment: rpc.CommitmentFinalized,
-			MaxRetries:          &retrie,
-			MinContextSlot:      nil,
-		},
-	)
+	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(txBytes))
 	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error sending transaction: %v", err)
+		return nil, fmt.Errorf("failed to decode transaction: %v", err)
 	}
 
-	time.Sleep(time.Second * 1)
+	return tx, nil
+}
 
-	maxAttempts := 15
-	for i := 0; i < maxAttempts; i++ {
-		response, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
-			Commitment: rpc.CommitmentConfirmed,
-		})
+// transactionInstructions decompiles the instructions of a server-built
+// transaction, with accounts from its lookup tables resolved. Signer and
+// writable roles are not restored.
+func transactionInstructions(ctx context.Context, client *rpc.Client, tx *solana.Transaction) ([]solana.Instruction, error) {
+	message := tx.Message
+	if lookups := message.GetAddressTableLookups(); len(lookups) > 0 {
+		tables, err := sender.LoadLookupTables(ctx, client, lookups.GetTableIDs())
 		if err != nil {
-			if i < maxAttempts-1 {
-				logger.Info("Attempt %d: Waiting for confirmation... (%v)", i+1, err)
-				time.Sleep(time.Second * 3)
-				continue
-			}
-			return sig, fmt.Errorf("transaction failed: %v", err)
+			return nil, err
+		}
+		if err := message.SetAddressTables(tables); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := message.GetAllKeys()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать аккаунты транзакции: %v", err)
+	}
+
+	instructions := make([]solana.Instruction, 0, len(message.Instructions))
+	for _, inst := range message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(keys) {
+			return nil, fmt.Errorf("некорректный индекс программы %d", inst.ProgramIDIndex)
 		}
 
-		if response != nil {
-			if response.Meta.Err != nil {
-				return sig, fmt.Errorf("transaction failed with error: %v", response.Meta.Err)
+		accounts := make(solana.AccountMetaSlice, len(inst.Accounts))
+		for i, index := range inst.Accounts {
+			if int(index) >= len(keys) {
+				return nil, fmt.Errorf("некорректный индекс аккаунта %d", index)
 			}
-			logger.Success("Transaction sent succesfully: %s%s", constants.EclipseScan, sig)
-			return sig, nil
+			accounts[i] = &solana.AccountMeta{PublicKey: keys[index]}
 		}
 
-		time.Sleep(time.Second * 3)
+		instructions = append(instructions, solana.NewInstruction(keys[inst.ProgramIDIndex], accounts, inst.Data))
+	}
+
+	return instructions, nil
+}
+
+// swapInputAccount returns the token account Solar should debit, or an empty
+// string for native ETH.
+func swapInputAccount(ctx context.Context, client *rpc.Client, owner, mint solana.PublicKey) (string, error) {
+	if mint.Equals(configs.MustToken("ETH").Address) {
+		return "", nil
+	}
+
+	ata, _, err := token.ResolveATA(ctx, client, owner, mint)
+	if err != nil {
+		return "", err
 	}
 
-	return sig, fmt.Errorf("transaction not confirmed after %d attempts", maxAttempts)
+	return ata.String(), nil
 }
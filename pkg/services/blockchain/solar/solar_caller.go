@@ -2,41 +2,50 @@
 
 import (
 	"context"
-	"database/sql"
 	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/base"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
-	"eclipse/pkg/services/blockchain/lifinity"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
 	"eclipse/pkg/services/database"
 	"eclipse/pkg/services/randomizer"
-	"eclipse/pkg/services/telegram"
 	"eclipse/utils/balance"
 	"fmt"
-	"net/http"
 	"strconv"
 	"time"
-
-	"github.com/gagliardetto/solana-go/rpc"
 )
 
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	httpClient http.Client,
-	client *rpc.Client,
-	cfg configs.AppConfig,
-	acc *model.EclipseAccount,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Solar",
+		ConfigKey: "solar",
+		IsSwap:    true,
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Solar Swap")
 
+	cfg := exec.Config
+	acc := exec.EclipseAccount
+	httpClient := exec.HttpClient
+	client := exec.RpcClient
+	notifier := exec.Notifier
+	db := exec.DB
+	maxAttempts := exec.MaxAttempts()
+	tracker := sender.NewTracker(client)
+	policy := exec.FeePolicy()
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		if cfg.Modules.Mode == "eth" {
 			amountDecimals, err := balance.GetUSDCBalance(ctx, client, acc.PublicKey)
 			if err != nil {
@@ -51,8 +60,8 @@ func (m *Module) Execute(
 
 			params := token.SwapInstructions{
 				Payer:         acc.PrivateKey,
-				FirstToken:    lifinity.USDC,
-				SecondToken:   lifinity.WETH,
+				FirstToken:    configs.MustToken("USDC").Address,
+				SecondToken:   configs.MustToken("ETH").Address,
 				Amount:        amountDecimals,
 				IsETH:         false,
 				TokenSymbol:   "USDC",
@@ -66,9 +75,10 @@ func (m *Module) Execute(
 			}
 
 			swapParams := SwapParams{
-				Amount:    strconv.FormatUint(amountDecimals, 10),
-				FromToken: lifinity.USDC,
-				ToToken:   lifinity.WETH,
+				Amount:      strconv.FormatUint(amountDecimals, 10),
+				FromToken:   configs.MustToken("USDC").Address,
+				ToToken:     configs.MustToken("ETH").Address,
+				SlippageBps: cfg.Slippage.Solar,
 			}
 
 			swapResponse, err := GetSolarSwapCompute(httpClient, swapParams)
@@ -77,21 +87,26 @@ func (m *Module) Execute(
 				continue
 			}
 
-			destinationATA, _, err := token.FindAssociatedTokenAddress2022(acc.PublicKey, lifinity.USDC)
+			inputAccount, err := swapInputAccount(ctx, client, acc.PublicKey, swapParams.FromToken)
 			if err != nil {
 				return false, fmt.Errorf("error getting ATA: %v", err)
 			}
 
-			txResponse, err := CreateSwapTransaction(httpClient, acc.PublicKey.String(), destinationATA.String(), swapResponse)
+			txResponse, err := CreateSwapTransaction(httpClient, acc.PublicKey.String(), inputAccount, swapResponse, ComputeUnitPrice(ctx, policy, swapResponse.Data.RoutePlan))
 			if err != nil {
 				logger.Error("Ошибка создания транзакции (попытка %d/%d): %v", attempt+1, maxAttempts, err)
 				continue
 			}
 
-			sig, err := ExecuteSwapFromInstructions(ctx, client, txResponse.Data[0].Transaction, acc.PrivateKey)
+			sig, err := ExecuteSwapFromInstructions(ctx, client, txResponse.Data[0].Transaction, acc.PrivateKey, tracker)
 			if err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			}
 
@@ -168,7 +183,7 @@ func (m *Module) Execute(
 				FirstToken:    firstPair.Address,
 				SecondToken:   secondPair.Address,
 				Amount:        amountDecimals,
-				IsETH:         firstPair.Address.String() == lifinity.WETH.String(),
+				IsETH:         firstPair.Address.String() == configs.MustToken("ETH").Address.String(),
 				TokenSymbol:   firstPair.Symbol,
 				TokenDecimals: firstPair.Decimals,
 			}
@@ -180,9 +195,10 @@ func (m *Module) Execute(
 			}
 
 			swapParams := SwapParams{
-				Amount:    valueStr,
-				FromToken: firstPair.Address,
-				ToToken:   secondPair.Address,
+				Amount:      valueStr,
+				FromToken:   firstPair.Address,
+				ToToken:     secondPair.Address,
+				SlippageBps: cfg.Slippage.Solar,
 			}
 
 			swapResponse, err := GetSolarSwapCompute(httpClient, swapParams)
@@ -190,20 +206,24 @@ func (m *Module) Execute(
 				return false, fmt.Errorf("error getting swap compute: %v", err)
 			}
 
-			destinationATA, _, err := token.FindAssociatedTokenAddress2022(acc.PublicKey, lifinity.USDC)
-
+			inputAccount, err := swapInputAccount(ctx, client, acc.PublicKey, swapParams.FromToken)
 			if err != nil {
 				return false, fmt.Errorf("error getting ATA: %v", err)
 			}
 
-			txResponse, err := CreateSwapTransaction(httpClient, acc.PublicKey.String(), destinationATA.String(), swapResponse)
+			txResponse, err := CreateSwapTransaction(httpClient, acc.PublicKey.String(), inputAccount, swapResponse, ComputeUnitPrice(ctx, policy, swapResponse.Data.RoutePlan))
 			if err != nil {
 				return false, fmt.Errorf("error creating swap transaction: %v", err)
 			}
 
-			if sig, err := ExecuteSwapFromInstructions(ctx, client, txResponse.Data[0].Transaction, acc.PrivateKey); err != nil {
+			if sig, err := ExecuteSwapFromInstructions(ctx, client, txResponse.Data[0].Transaction, acc.PrivateKey, tracker); err != nil {
 				logger.Error("Ошибка свапа (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-				time.Sleep(3 * time.Second)
+				if err := tracker.Unresolved(); err != nil {
+					return false, err
+				}
+				if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+					return false, err
+				}
 				continue
 			} else {
 				formatString := fmt.Sprintf("%%.%df", firstPair.Decimals)
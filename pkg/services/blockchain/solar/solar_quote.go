@@ -0,0 +1,63 @@
+package solar
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/fees"
+	"fmt"
+	"strconv"
+)
+
+// QuoteUsdcToEth prices the transaction Solar builds for the swap, the
+// priority fee it sets and rent of accounts it keeps open.
+func (m *Module) QuoteUsdcToEth(ctx context.Context, exec interfaces.ExecutionContext, amount uint64) (interfaces.Quote, error) {
+	resp, err := GetSolarSwapCompute(exec.HttpClient, SwapParams{
+		Amount:      strconv.FormatUint(amount, 10),
+		FromToken:   configs.MustToken("USDC").Address,
+		ToToken:     configs.MustToken("ETH").Address,
+		SlippageBps: exec.Config.Slippage.Solar,
+	})
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+	if !resp.Success {
+		return interfaces.Quote{}, fmt.Errorf("solar не вернул маршрут")
+	}
+
+	out, err := strconv.ParseUint(resp.Data.OutputAmount, 10, 64)
+	if err != nil {
+		return interfaces.Quote{}, fmt.Errorf("invalid output amount %q: %v", resp.Data.OutputAmount, err)
+	}
+
+	usdc := configs.MustToken("USDC").Address
+	inputAccount, err := swapInputAccount(ctx, exec.RpcClient, exec.EclipseAccount.PublicKey, usdc)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	txResponse, err := CreateSwapTransaction(exec.HttpClient, exec.EclipseAccount.PublicKey.String(), inputAccount, resp, ComputeUnitPrice(ctx, exec.FeePolicy(), resp.Data.RoutePlan))
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+	if len(txResponse.Data) == 0 {
+		return interfaces.Quote{}, fmt.Errorf("solar не вернул транзакцию")
+	}
+
+	tx, err := decodeTransaction(txResponse.Data[0].Transaction)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	instructions, err := transactionInstructions(ctx, exec.RpcClient, tx)
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	fee, err := fees.RouteFee(ctx, exec.RpcClient, instructions, int(tx.Message.Header.NumRequiredSignatures))
+	if err != nil {
+		return interfaces.Quote{}, err
+	}
+
+	return interfaces.Quote{AmountOut: out, Fee: fee}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gagliardetto/solana-go"
 	"io"
 	"net/http"
+	"strconv"
 )
 
 type SolarSwapResponse struct {
@@ -40,13 +41,14 @@ type RoutePlan struct {
 }
 
 type SwapParams struct {
-	Amount    string
-	FromToken solana.PublicKey
-	ToToken   solana.PublicKey
+	Amount      string
+	FromToken   solana.PublicKey
+	ToToken     solana.PublicKey
+	SlippageBps int
 }
 
 func GetSolarSwapCompute(client http.Client, params SwapParams) (*SolarSwapResponse, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.solarstudios.co/compute/swap-base-in?inputMint=%s&outputMint=%s&amount=%s&slippageBps=50&txVersion=V0", params.FromToken.String(), params.ToToken.String(), params.Amount), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.solarstudios.co/compute/swap-base-in?inputMint=%s&outputMint=%s&amount=%s&slippageBps=%d&txVersion=V0", params.FromToken.String(), params.ToToken.String(), params.Amount, params.SlippageBps), nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %v", err)
 	}
@@ -98,7 +100,7 @@ type SwapRequest struct {
 	TxVersion                     string            `json:"txVersion"`
 	WrapSol                       bool              `json:"wrapSol"`
 	UnwrapSol                     bool              `json:"unwrapSol"`
-	InputAccount                  string            `json:"inputAccount"`
+	InputAccount                  string            `json:"inputAccount,omitempty"`
 }
 
 type TransactionResponse struct {
@@ -110,15 +112,17 @@ type TransactionResponse struct {
 	} `json:"data"`
 }
 
-func CreateSwapTransaction(client http.Client, wallet, outputAccount string, swapResponse *SolarSwapResponse) (*TransactionResponse, error) {
+// CreateSwapTransaction asks Solar for the swap transaction. An empty
+// inputAccount means the input is native ETH, which Solar wraps itself.
+func CreateSwapTransaction(client http.Client, wallet, inputAccount string, swapResponse *SolarSwapResponse, computeUnitPrice uint64) (*TransactionResponse, error) {
 	swapRequest := SwapRequest{
 		Wallet:                        wallet,
-		ComputeUnitPriceMicroLamports: "200000",
+		ComputeUnitPriceMicroLamports: strconv.FormatUint(computeUnitPrice, 10),
 		SwapResponse:                  *swapResponse,
-		TxVersion:                     "LEGACY",
-		WrapSol:                       false,
+		TxVersion:                     "V0",
+		WrapSol:                       inputAccount == "",
 		UnwrapSol:                     true,
-		InputAccount:                  outputAccount,
+		InputAccount:                  inputAccount,
 	}
 
 	jsonData, err := json.Marshal(swapRequest)
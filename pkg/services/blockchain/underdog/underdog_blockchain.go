@@ -2,17 +2,15 @@
 
 import (
 	"context"
-	"eclipse/constants"
-	"eclipse/internal/logger"
+	"eclipse/pkg/services/blockchain/sender"
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
-	"time"
 )
 
-func SendSolanaTransaction(ctx context.Context, client *rpc.Client, encodedTx string, userPrivateKey solana.PrivateKey) (solana.Signature, error) {
+func SendSolanaTransaction(ctx context.Context, client *rpc.Client, encodedTx string, userPrivateKey solana.PrivateKey, tracker *sender.Tracker) (solana.Signature, error) {
 	txBytes, err := base64.StdEncoding.DecodeString(encodedTx)
 	if err != nil {
 		return solana.Signature{}, fmt.Errorf("failed to decode base64: %v", err)
@@ -23,51 +21,23 @@ func SendSolanaTransaction(ctx context.Context, client *rpc.Client, encodedTx st
 		return solana.Signature{}, fmt.Errorf("failed to decode transaction: %v", err)
 	}
 
-	messageBytes, _ := tx.Message.MarshalBinary()
-	newSignature, _ := userPrivateKey.Sign(messageBytes)
-	tx.Signatures[0] = newSignature
-
-	retries := uint(5)
-	version := uint64(0)
-
-	sig, err := client.SendTransactionWithOpts(ctx, tx,
-		rpc.TransactionOpts{
-			SkipPreflight:       true,
-			PreflightCommitment: rpc.CommitmentFinalized,
-			MaxRetries:          &retries,
-		},
-	)
-	if err != nil {
-		return solana.Signature{}, fmt.Errorf("error sending transaction: %v", err)
+	// signatures the server made are kept, so the message is signed exactly as
+	// it came
+	if _, err := sender.Rewrite(ctx, client, tx, sender.RewriteOptions{}, userPrivateKey); err != nil {
+		return solana.Signature{}, err
 	}
 
-	maxAttempts := 15
-	for i := 0; i < maxAttempts; i++ {
-		response, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
-			Commitment:                     rpc.CommitmentConfirmed,
-			MaxSupportedTransactionVersion: &version,
-		})
-		if err != nil {
-			if i < maxAttempts-1 {
-				logger.Info("Attempt %d: Waiting for confirmation... (%v)", i+1, err)
-				time.Sleep(time.Second * 3)
-				continue
-			}
-			return sig, fmt.Errorf("transaction failed: %v", err)
-		}
+	opts := sender.DefaultOptions()
+	opts.SkipPreflight = true
+	opts.PreflightCommitment = rpc.CommitmentFinalized
+	opts.Tracker = tracker
 
-		if response != nil {
-			if response.Meta.Err != nil {
-				return sig, fmt.Errorf("transaction failed with error: %v", response.Meta.Err)
-			}
-			logger.Success("Transaction sent succesfully: %s%s", constants.EclipseScan, sig)
-			return sig, nil
-		}
-
-		time.Sleep(time.Second * 3)
+	result, err := sender.SendAndConfirm(ctx, client, tx, opts)
+	if err != nil {
+		return result.Signature, fmt.Errorf("error sending transaction: %w", err)
 	}
 
-	return sig, fmt.Errorf("transaction not confirmed after %d attempts", maxAttempts)
+	return result.Signature, nil
 }
 
 func EstimateTransactionFee(ctx context.Context, client *rpc.Client, encodedTx string) (uint64, error) {
@@ -2,39 +2,44 @@
 
 import (
 	"context"
-	"database/sql"
-	"eclipse/configs"
 	"eclipse/constants"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
-	"eclipse/model"
+	"eclipse/pkg/interfaces"
+	"eclipse/pkg/services/blockchain/sender"
 	"eclipse/pkg/services/database"
-	"eclipse/pkg/services/telegram"
+	"eclipse/pkg/services/randomizer"
 	"eclipse/utils/balance"
 	"eclipse/utils/requester"
 	"fmt"
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 	"math/rand"
-	"net/http"
 	"time"
 )
 
 type Module struct{}
 
-func (m *Module) Execute(
-	ctx context.Context,
-	httpClient http.Client,
-	client *rpc.Client,
-	cfg configs.AppConfig,
-	acc *model.EclipseAccount,
-	notifier *telegram.Notifier,
-	db *sql.DB,
-	words []string,
-	minEthHold float64,
-	maxAttempts int,
-) (bool, error) {
+func init() {
+	interfaces.Register(interfaces.ModuleInfo{
+		Name:      "Underdog",
+		ConfigKey: "underdog",
+		Module:    &Module{},
+	})
+}
+
+func (m *Module) Execute(ctx context.Context, exec interfaces.ExecutionContext) (bool, error) {
 	logger.Info("Начал выполнение модуля Underdog Create Collection")
+
+	cfg := exec.Config
+	acc := exec.EclipseAccount
+	httpClient := exec.HttpClient
+	client := exec.RpcClient
+	notifier := exec.Notifier
+	db := exec.DB
+	words := exec.Words
+	minEthHold := cfg.MinEthHold
+	maxAttempts := exec.MaxAttempts()
+	tracker := sender.NewTracker(client)
 	rand.Seed(time.Now().UnixNano())
 
 	count, err := database.GetModuleCountForWallet(db, acc.PublicKey.String(), "Underdog")
@@ -49,6 +54,10 @@ func (m *Module) Execute(
 	}
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		word1 := words[rand.Intn(len(words))]
 		word2 := words[rand.Intn(len(words))]
 		name := fmt.Sprintf("%s %s", word1, word2)
@@ -117,10 +126,15 @@ func (m *Module) Execute(
 			logger.Info("Баланса достаточно чтобы производить создание новой коллекции")
 		}
 
-		sig, err := SendSolanaTransaction(ctx, client, res, acc.PrivateKey)
+		sig, err := SendSolanaTransaction(ctx, client, res, acc.PrivateKey, tracker)
 		if err != nil {
 			logger.Error("error creating collection from tx (попытка %d/%d): %v", attempt+1, maxAttempts, err)
-			time.Sleep(3 * time.Second)
+			if err := tracker.Unresolved(); err != nil {
+				return false, err
+			}
+			if err := randomizer.Sleep(ctx, 3*time.Second); err != nil {
+				return false, err
+			}
 			continue
 		}
 
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"database/sql"
+	"eclipse/internal/logger"
+	"time"
+)
+
+const (
+	BridgeStatusFilled   = "filled"
+	BridgeStatusFailed   = "failed"
+	BridgeStatusRefunded = "refunded"
+	BridgeStatusUnknown  = "unknown"
+)
+
+type BridgeRecord struct {
+	Wallet       string
+	Provider     string
+	RequestID    string
+	OriginChain  string
+	OriginTxHash string
+	Destination  string
+	Token        string
+	FillTxHash   string
+	Amount       string
+	Status       string
+	Elapsed      time.Duration
+}
+
+func AddBridge(db *sql.DB, r BridgeRecord) error {
+	logger.Info("Adding bridge in db: wallet=%s, provider=%s, request=%s, %s -> %s, token=%s, origin=%s, fill=%s, status=%s",
+		r.Wallet, r.Provider, r.RequestID, r.OriginChain, r.Destination, r.Token, r.OriginTxHash, r.FillTxHash, r.Status)
+
+	_, err := db.Exec(`
+        INSERT INTO bridges (
+            wallet_address,
+            provider,
+            request_id,
+            origin_chain,
+            origin_tx_hash,
+            destination_chain,
+            token_name,
+            fill_tx_hash,
+            amount,
+            status,
+            elapsed_seconds,
+            created_at
+        )
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, r.Wallet, r.Provider, r.RequestID, r.OriginChain, r.OriginTxHash, r.Destination, r.Token, r.FillTxHash, r.Amount, r.Status,
+		int64(r.Elapsed.Seconds()), time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		logger.Error("Error adding bridge: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -7,8 +7,8 @@ import (
 	"time"
 )
 
-func InitDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "../data/modules.db")
+func InitDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
@@ -28,6 +28,63 @@ func InitDB() (*sql.DB, error) {
 		return nil, err
 	}
 
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS runs (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            mode TEXT NOT NULL,
+            status TEXT NOT NULL,
+            created_at DATETIME DEFAULT (datetime('now', 'utc')),
+            finished_at DATETIME
+        )
+    `)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS run_items (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            run_id INTEGER NOT NULL REFERENCES runs(id),
+            account_index INTEGER NOT NULL,
+            wallet_address TEXT NOT NULL,
+            position INTEGER NOT NULL,
+            module_name TEXT NOT NULL,
+            status TEXT NOT NULL,
+            error TEXT NOT NULL DEFAULT '',
+            attempts TEXT NOT NULL DEFAULT '',
+            updated_at DATETIME DEFAULT (datetime('now', 'utc')),
+            UNIQUE (run_id, wallet_address, position)
+        )
+    `)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS bridges (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            wallet_address TEXT NOT NULL,
+            provider TEXT NOT NULL,
+            request_id TEXT NOT NULL,
+            origin_chain TEXT NOT NULL,
+            origin_tx_hash TEXT NOT NULL,
+            destination_chain TEXT NOT NULL,
+            token_name TEXT NOT NULL,
+            fill_tx_hash TEXT NOT NULL DEFAULT '',
+            amount TEXT NOT NULL,
+            status TEXT NOT NULL,
+            elapsed_seconds INTEGER NOT NULL DEFAULT 0,
+            created_at DATETIME DEFAULT (datetime('now', 'utc'))
+        )
+    `)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer; workers share one connection instead of
+	// failing with "database is locked"
+	db.SetMaxOpenConns(1)
+
 	return db, nil
 }
 
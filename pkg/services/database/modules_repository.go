@@ -59,3 +59,43 @@ func GetAllModuleCountsForWallet(db *sql.DB, walletAddress string) (map[string]i
 
 	return counts, nil
 }
+
+type ModuleRecord struct {
+	ID        int64  `json:"id"`
+	Wallet    string `json:"wallet"`
+	Dex       string `json:"dex"`
+	Amount    string `json:"amount"`
+	Token     string `json:"token"`
+	TxHash    string `json:"tx_hash"`
+	CreatedAt string `json:"created_at"`
+}
+
+func GetModules(db *sql.DB) ([]ModuleRecord, error) {
+	rows, err := db.Query(`
+        SELECT id, wallet_address, dex_name, amount, token_name, tx_hash, created_at
+        FROM modules
+        ORDER BY id
+    `)
+	if err != nil {
+		logger.Error("Error getting modules: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]ModuleRecord, 0)
+	for rows.Next() {
+		var r ModuleRecord
+		if err := rows.Scan(&r.ID, &r.Wallet, &r.Dex, &r.Amount, &r.Token, &r.TxHash, &r.CreatedAt); err != nil {
+			logger.Error("Error scanning row: %v", err)
+			return nil, err
+		}
+		records = append(records, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error("Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return records, nil
+}
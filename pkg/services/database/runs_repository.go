@@ -0,0 +1,252 @@
+package database
+
+import (
+	"database/sql"
+	"eclipse/internal/logger"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	RunStatusRunning     = "running"
+	RunStatusCompleted   = "completed"
+	RunStatusInterrupted = "interrupted"
+
+	ItemStatusPending   = "pending"
+	ItemStatusCompleted = "completed"
+	ItemStatusFailed    = "failed"
+	// ItemStatusUnresolved is a module whose transaction may still land,
+	// Attempts holds what is needed to check it before running it again.
+	ItemStatusUnresolved = "unresolved"
+)
+
+type Run struct {
+	ID        int64
+	Mode      string
+	Status    string
+	CreatedAt string
+}
+
+type RunItem struct {
+	ID           int64
+	RunID        int64
+	AccountIndex int
+	Wallet       string
+	Position     int
+	ModuleName   string
+	Status       string
+	Error        string
+	Attempts     string
+}
+
+func CreateRun(db *sql.DB, mode string) (int64, error) {
+	res, err := db.Exec(`
+        INSERT INTO runs (mode, status, created_at)
+        VALUES (?, ?, ?)
+    `, mode, RunStatusRunning, time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		logger.Error("Error creating run: %v", err)
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func GetRun(db *sql.DB, runID int64) (*Run, error) {
+	var run Run
+
+	err := db.QueryRow(`
+        SELECT id, mode, status, created_at
+        FROM runs
+        WHERE id = ?
+    `, runID).Scan(&run.ID, &run.Mode, &run.Status, &run.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("запуск #%d не найден", runID)
+	}
+	if err != nil {
+		logger.Error("Error getting run %d: %v", runID, err)
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func SetRunStatus(db *sql.DB, runID int64, status string) error {
+	var finishedAt any
+	if status != RunStatusRunning {
+		finishedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	_, err := db.Exec(`
+        UPDATE runs
+        SET status = ?, finished_at = ?
+        WHERE id = ?
+    `, status, finishedAt, runID)
+	if err != nil {
+		logger.Error("Error updating run %d: %v", runID, err)
+		return err
+	}
+
+	return nil
+}
+
+func GetRunItems(db *sql.DB, runID int64, wallet string) ([]RunItem, error) {
+	rows, err := db.Query(`
+        SELECT id, run_id, account_index, wallet_address, position, module_name, status, error, attempts
+        FROM run_items
+        WHERE run_id = ? AND wallet_address = ?
+        ORDER BY position
+    `, runID, wallet)
+	if err != nil {
+		logger.Error("Error getting run items for wallet %s: %v", wallet, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []RunItem
+	for rows.Next() {
+		var item RunItem
+		if err := rows.Scan(&item.ID, &item.RunID, &item.AccountIndex, &item.Wallet, &item.Position, &item.ModuleName, &item.Status, &item.Error, &item.Attempts); err != nil {
+			logger.Error("Error scanning row: %v", err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error("Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func SaveRunPlan(db *sql.DB, runID int64, accountIndex int, wallet string, modules []string) ([]RunItem, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	items := make([]RunItem, 0, len(modules))
+	for position, module := range modules {
+		res, err := tx.Exec(`
+            INSERT INTO run_items (run_id, account_index, wallet_address, position, module_name, status)
+            VALUES (?, ?, ?, ?, ?, ?)
+        `, runID, accountIndex, wallet, position, module, ItemStatusPending)
+		if err != nil {
+			logger.Error("Error saving run plan for wallet %s: %v", wallet, err)
+			return nil, err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, RunItem{
+			ID:           id,
+			RunID:        runID,
+			AccountIndex: accountIndex,
+			Wallet:       wallet,
+			Position:     position,
+			ModuleName:   module,
+			Status:       ItemStatusPending,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func SetRunItemStatus(db *sql.DB, itemID int64, status, errText string) error {
+	return setRunItem(db, itemID, status, errText, "")
+}
+
+// SetRunItemUnresolved marks an item whose transactions may still land and
+// keeps attempts, the serialized transactions, for the next --resume.
+func SetRunItemUnresolved(db *sql.DB, itemID int64, errText, attempts string) error {
+	return setRunItem(db, itemID, ItemStatusUnresolved, errText, attempts)
+}
+
+func setRunItem(db *sql.DB, itemID int64, status, errText, attempts string) error {
+	_, err := db.Exec(`
+        UPDATE run_items
+        SET status = ?, error = ?, attempts = ?, updated_at = ?
+        WHERE id = ?
+    `, status, errText, attempts, time.Now().Format("2006-01-02 15:04:05"), itemID)
+	if err != nil {
+		logger.Error("Error updating run item %d: %v", itemID, err)
+		return err
+	}
+
+	return nil
+}
+
+func ListRuns(db *sql.DB, limit int) ([]Run, error) {
+	rows, err := db.Query(`
+        SELECT id, mode, status, created_at
+        FROM runs
+        ORDER BY id DESC
+        LIMIT ?
+    `, limit)
+	if err != nil {
+		logger.Error("Error listing runs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.ID, &run.Mode, &run.Status, &run.CreatedAt); err != nil {
+			logger.Error("Error scanning row: %v", err)
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error("Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func GetRunItemStats(db *sql.DB, runID int64) (map[string]int, error) {
+	stats := make(map[string]int)
+
+	rows, err := db.Query(`
+        SELECT status, COUNT(*)
+        FROM run_items
+        WHERE run_id = ?
+        GROUP BY status
+    `, runID)
+	if err != nil {
+		logger.Error("Error getting run item stats for run %d: %v", runID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			logger.Error("Error scanning row: %v", err)
+			return nil, err
+		}
+		stats[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error("Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -1,6 +1,7 @@
 ﻿package randomizer
 
 import (
+	"context"
 	"eclipse/internal/base"
 	"eclipse/internal/logger"
 	"math"
@@ -26,7 +27,7 @@ func GetRandomValueWithPrecision(minValue, maxValue float64, minPrecision, maxPr
 	return roundedValue, weiInt.String()
 }
 
-func RandomDelay(min, max float64, inMinutes bool) {
+func RandomDelay(ctx context.Context, min, max float64, inMinutes bool) error {
 	delayRange := max - min
 	randomDelay := min + rand.Float64()*delayRange
 
@@ -42,5 +43,17 @@ func RandomDelay(min, max float64, inMinutes bool) {
 	}
 
 	logger.Info("Ожидание выполнения: %.2f %s\n", randomDelay, unitStr)
-	time.Sleep(delayDuration)
+	return Sleep(ctx, delayDuration)
+}
+
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,246 @@
+package rpcpool
+
+import (
+	"context"
+	"eclipse/configs"
+	"eclipse/internal/logger"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	maxBackoff     = time.Minute
+)
+
+type endpoint struct {
+	http      string
+	ws        string
+	client    jsonrpc.RPCClient
+	latency   time.Duration
+	failures  int
+	downUntil time.Time
+}
+
+// Pool is a JSON-RPC client that spreads calls over several Eclipse
+// endpoints. Calls go to the fastest healthy endpoint and fail over to the
+// next one on 429, 5xx and transport errors. It is safe for concurrent use.
+type Pool struct {
+	endpoints []*endpoint
+	interval  time.Duration
+	mutex     sync.Mutex
+}
+
+func New(cfg configs.RPCConfig) *Pool {
+	httpClient := &http.Client{Timeout: requestTimeout}
+
+	pool := &Pool{interval: time.Duration(cfg.HealthCheckInterval) * time.Second}
+	for _, e := range cfg.Eclipse {
+		pool.endpoints = append(pool.endpoints, &endpoint{
+			http:   e.HTTP,
+			ws:     e.WS,
+			client: jsonrpc.NewClientWithOpts(e.HTTP, &jsonrpc.RPCClientOpts{HTTPClient: httpClient}),
+		})
+	}
+
+	return pool
+}
+
+func (p *Pool) Client() *rpc.Client {
+	return rpc.NewWithCustomRPCClient(p)
+}
+
+// WSEndpoint returns the websocket url of the best endpoint that has one.
+func (p *Pool) WSEndpoint() string {
+	for _, e := range p.ordered() {
+		if e.ws != "" {
+			return e.ws
+		}
+	}
+	return ""
+}
+
+// Start checks every endpoint once and then keeps checking them in the
+// background until ctx is cancelled.
+func (p *Pool) Start(ctx context.Context) {
+	p.checkAll(ctx)
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkAll(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Pool) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			p.check(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) check(ctx context.Context, e *endpoint) {
+	checkCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	var health string
+	start := time.Now()
+	err := e.client.CallForInto(checkCtx, &health, "getHealth", nil)
+	if err == nil && health != "ok" {
+		err = fmt.Errorf("getHealth returned %q", health)
+	}
+
+	// shutting down, the endpoint did nothing wrong
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		p.markDown(e, err)
+		return
+	}
+	p.markUp(e, time.Since(start))
+}
+
+// markUp records that e answered. Only the getHealth probe passes a latency,
+// the time of other calls depends on the method and would skew the ranking.
+func (p *Pool) markUp(e *endpoint, latency time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if latency > 0 {
+		if e.latency == 0 {
+			e.latency = latency
+		} else {
+			e.latency = (e.latency*3 + latency) / 4
+		}
+	}
+	if e.failures > 0 {
+		logger.Info("RPC %s снова доступен", e.http)
+	}
+	e.failures = 0
+	e.downUntil = time.Time{}
+}
+
+func (p *Pool) markDown(e *endpoint, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	e.failures++
+	backoff := time.Second << min(e.failures, 6)
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	e.downUntil = time.Now().Add(backoff)
+
+	logger.Warning("RPC %s недоступен на %s: %v", e.http, backoff, err)
+}
+
+// ordered returns healthy endpoints by latency, followed by the ones in
+// backoff so a call still has somewhere to go when every endpoint is down.
+func (p *Pool) ordered() []*endpoint {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	endpoints := make([]*endpoint, len(p.endpoints))
+	copy(endpoints, p.endpoints)
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		aDown, bDown := now.Before(a.downUntil), now.Before(b.downUntil)
+		if aDown != bDown {
+			return !aDown
+		}
+		if aDown {
+			return a.downUntil.Before(b.downUntil)
+		}
+		return a.latency < b.latency
+	})
+
+	return endpoints
+}
+
+func (p *Pool) call(ctx context.Context, fn func(client jsonrpc.RPCClient) error) error {
+	var lastErr error
+
+	for _, e := range p.ordered() {
+		err := fn(e.client)
+		if err == nil || !isRetryable(ctx, err) {
+			p.markUp(e, 0)
+			return err
+		}
+
+		p.markDown(e, err)
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+func (p *Pool) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return p.call(ctx, func(client jsonrpc.RPCClient) error {
+		return client.CallForInto(ctx, out, method, params)
+	})
+}
+
+func (p *Pool) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return p.call(ctx, func(client jsonrpc.RPCClient) error {
+		return client.CallWithCallback(ctx, method, params, callback)
+	})
+}
+
+func (p *Pool) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	var responses jsonrpc.RPCResponses
+	err := p.call(ctx, func(client jsonrpc.RPCClient) error {
+		var err error
+		responses, err = client.CallBatch(ctx, requests)
+		return err
+	})
+	return responses, err
+}
+
+// isRetryable reports whether err means the endpoint itself is in trouble,
+// as opposed to the request being wrong, so another endpoint may succeed.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code == http.StatusTooManyRequests || httpErr.Code >= 500
+	}
+
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		// -32005: node is behind, -32004: block not available
+		return rpcErr.Code == http.StatusTooManyRequests || rpcErr.Code == -32005 || rpcErr.Code == -32004
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
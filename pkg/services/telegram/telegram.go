@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"sync"
@@ -32,6 +33,14 @@ func NewNotifier(botToken string, chatID int64) (*Notifier, error) {
 	}, nil
 }
 
+// NewDisabledNotifier collects messages like a regular notifier but never
+// sends them, for runs with telegram turned off or in dry-run mode.
+func NewDisabledNotifier() *Notifier {
+	return &Notifier{
+		walletMessages: make(map[string][]MessageWithEntity),
+	}
+}
+
 func (t *Notifier) AddMessageForWallet(walletAddress string, message string) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -76,7 +85,7 @@ func (t *Notifier) SendWalletMessages(walletAddress string) error {
 	}
 	t.mutex.Unlock()
 
-	if !exists || len(messages) == 0 {
+	if !exists || len(messages) == 0 || t.bot == nil {
 		return nil
 	}
 
@@ -110,6 +119,24 @@ func (t *Notifier) SendWalletMessages(walletAddress string) error {
 	return err
 }
 
+func (t *Notifier) Flush() error {
+	t.mutex.Lock()
+	wallets := make([]string, 0, len(t.walletMessages))
+	for wallet := range t.walletMessages {
+		wallets = append(wallets, wallet)
+	}
+	t.mutex.Unlock()
+
+	var errs []error
+	for _, wallet := range wallets {
+		if err := t.SendWalletMessages(wallet); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", wallet, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (t *Notifier) ClearAllMessages() {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -2,12 +2,13 @@
 
 import (
 	"context"
+	"eclipse/configs"
 	"eclipse/internal/logger"
 	"eclipse/internal/token"
+	"eclipse/pkg/services/randomizer"
 	"fmt"
 	"math"
 	"math/big"
-	"strconv"
 	"time"
 
 	"github.com/gagliardetto/solana-go/rpc"
@@ -26,28 +27,11 @@ func GetTokenBalance(ctx context.Context, client *rpc.Client, params token.SwapI
 
 		return balance.Value, nil
 	} else {
-		tokenAccount, _, err := token.FindAssociatedTokenAddress2022(
-			params.Payer.PublicKey(),
-			params.FirstToken,
-		)
-		if err != nil {
-			return 0, fmt.Errorf("ошибка получения токена аккаунта: %v", err)
-		}
-
-		balance, err := client.GetTokenAccountBalance(
-			ctx,
-			tokenAccount,
-			rpc.CommitmentFinalized,
-		)
+		amount, err := token.GetATABalance(ctx, client, params.Payer.PublicKey(), params.FirstToken, rpc.CommitmentFinalized)
 		if err != nil {
 			return 0, fmt.Errorf("ошибка получения баланса аккаунта: %v", err)
 		}
 
-		amount, err := strconv.ParseUint(balance.Value.Amount, 10, 64)
-		if err != nil {
-			return 0, fmt.Errorf("ошибка парсинга баланса аккаунта: %v", err)
-		}
-
 		return amount, nil
 	}
 }
@@ -59,6 +43,11 @@ func CheckAndWaitForBalance(ctx context.Context, client *rpc.Client, params toke
 	if !params.IsETH {
 		tokenName = params.TokenSymbol
 		decimals = float64(params.TokenDecimals)
+
+		if t, exists := configs.TokenByMint(params.FirstToken); exists {
+			tokenName = t.Symbol
+			decimals = float64(t.Decimals)
+		}
 	}
 
 	if params.IsETH {
@@ -77,7 +66,9 @@ func CheckAndWaitForBalance(ctx context.Context, client *rpc.Client, params toke
 		balance, err := GetTokenBalance(ctx, client, params)
 		if err != nil {
 			logger.Error("Ошибка проверки баланса %s (попытка %d/%d): %v", tokenName, i+1, maxAttempts, err)
-			time.Sleep(time.Second * 3)
+			if err := randomizer.Sleep(ctx, time.Second*3); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -93,7 +84,9 @@ func CheckAndWaitForBalance(ctx context.Context, client *rpc.Client, params toke
 			return nil
 		}
 
-		time.Sleep(time.Second * 1)
+		if err := randomizer.Sleep(ctx, time.Second*1); err != nil {
+			return err
+		}
 	}
 
 	return fmt.Errorf("не найден баланс %s после %d попыток", tokenName, maxAttempts)